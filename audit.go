@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuditEntry records one change made through the API. Before and After hold
+// the movie, genre, person, credit or snapshot as it was before and after the
+// change, and are null when it did not exist or is not known, like a movie
+// in the trash.
+type AuditEntry struct {
+	AuditID   int             `json:"auditid"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	MovieID   string          `json:"movieid,omitempty"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit entries. Zero fields do not filter.
+type AuditFilter struct {
+	MovieID string
+	Actor   string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+type AuditResponse struct {
+	Type    string       `json:"type"`
+	Data    []AuditEntry `json:"data"`
+	Message string       `json:"message"`
+}
+
+const (
+	// actorHeader names who is making the request. There is no
+	// authentication, so it is taken on trust.
+	actorHeader    = "X-Actor"
+	anonymousActor = "anonymous"
+	// cliActor is the actor of changes made by subcommands
+	cliActor = "cli"
+
+	requestIDHeader = "X-Request-ID"
+
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type requestIDKey struct{}
+
+// withRequestID gives every request an ID, taken from the X-Request-ID
+// header when the client sends one, and echoes it in the response
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, reader *http.Request) {
+		id := reader.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = randomID()
+		}
+
+		writer.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(writer, reader.WithContext(context.WithValue(reader.Context(), requestIDKey{}, id)))
+	})
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// notAuditedMessage answers a request whose change could not be recorded in
+// the audit log, and so has not been made
+const notAuditedMessage = "The change could not be recorded in the audit log, so it has not been made."
+
+// errNotAudited is returned by audited when the audit entries cannot be written
+var errNotAudited = errors.New("the change could not be recorded in the audit log")
+
+// audited makes a change and records it in the audit log in one
+// transaction, so that no change is ever made without its entries. fn makes
+// the change through the store it is given and returns the entries
+// describing it, see auditEntry. An error from fn, or errNotAudited when the
+// entries cannot be written, discards the change.
+func (s *server) audited(reader *http.Request, fn func(store Store) ([]AuditEntry, error)) error {
+	return s.auditedAs(reader.Context(), requestActor(reader), fn)
+}
+
+// auditedAs is audited for changes made by the given actor, like those of
+// the import subcommand, which are not made by a request
+func (s *server) auditedAs(ctx context.Context, actor string, fn func(store Store) ([]AuditEntry, error)) error {
+	return s.store.Atomically(ctx, func(store Store) error {
+		entries, err := fn(store)
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			entries[i].Actor = actor
+			entries[i].RequestID = requestID(ctx)
+		}
+
+		if err := store.RecordAudit(ctx, entries...); err != nil {
+			log.Println("Failed to record", len(entries), "audit entries:", err)
+			return errNotAudited
+		}
+
+		return nil
+	})
+}
+
+// auditEntry describes a change to the movie with the given movieid, or to
+// something else when movieID is empty, for audited
+func auditEntry(action string, movieID string, before interface{}, after interface{}) AuditEntry {
+	return AuditEntry{Action: action, MovieID: movieID, Before: auditJSON(before), After: auditJSON(after)}
+}
+
+func requestActor(reader *http.Request) string {
+	if actor := reader.Header.Get(actorHeader); actor != "" {
+		return actor
+	}
+
+	return anonymousActor
+}
+
+func auditJSON(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Println("Failed to encode audit data:", err)
+		return nil
+	}
+
+	return data
+}
+
+// listAudit godoc
+// @Description Get the audit log of changes made through the API, newest first.
+// @Description Every change is recorded in the same transaction as the change itself, and a change that cannot be recorded is not made and fails its request with a 500.
+// @Produce json
+// @Param movieid query string false "Only changes to this movie"
+// @Param actor query string false "Only changes made by this actor"
+// @Param since query string false "Only changes at or after this time, in RFC 3339"
+// @Param until query string false "Only changes before this time, in RFC 3339"
+// @Param limit query int false "At most this many entries, 100 by default and 1000 at most"
+// @Success 200 {object} AuditResponse{type=string,data=[]AuditEntry,message=string} "Successfully get the audit log"
+// @Failure 400 {object} AuditResponse{type=string,message=string} "A filter is invalid"
+// @Failure 500 {object} AuditResponse{type=string,message=string} "Fail to get the audit log"
+// @Router /audit/ [get]
+func (s *server) listAudit(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /audit")
+
+	query := reader.URL.Query()
+	filter := AuditFilter{MovieID: query.Get("movieid"), Actor: query.Get("actor"), Limit: defaultAuditLimit}
+
+	var err error
+	if value := query.Get("since"); value != "" && err == nil {
+		filter.Since, err = time.Parse(time.RFC3339, value)
+	}
+	if value := query.Get("until"); value != "" && err == nil {
+		filter.Until, err = time.Parse(time.RFC3339, value)
+	}
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(AuditResponse{Type: "error", Message: "since and until must be RFC 3339 times, like 2006-01-02T15:04:05Z"})
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(AuditResponse{Type: "error", Message: "limit must be a number from 1 to 1000"})
+			return
+		}
+	}
+
+	entries, err := s.store.ListAudit(reader.Context(), filter)
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(AuditResponse{Type: "error", Message: "Failed to get the audit log from the database"})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(AuditResponse{Type: "success", Data: entries, Message: "Successfully got the audit log from DB"})
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 50
+
+	// titleIndexMaxAge bounds how long changes made through another server
+	// sharing the database can go unseen
+	titleIndexMaxAge = time.Minute
+)
+
+type AutocompleteResponse struct {
+	Type    string       `json:"type"`
+	Data    []MovieTitle `json:"data"`
+	Message string       `json:"message"`
+	Errors  []ParamError `json:"errors,omitempty"`
+}
+
+// titleIndex holds the titles of every movie in memory, so that
+// autocompletion and suggestions do not query the database. It is loaded
+// from the store on first use and kept up to date by the handlers that add
+// and remove movies. Changes it cannot follow one by one mark it stale,
+// and it is loaded again on the next lookup.
+type titleIndex struct {
+	load func(ctx context.Context) ([]MovieTitle, error)
+
+	mu     sync.RWMutex
+	titles map[string]MovieTitle
+	// entries are sorted by key, for binary search
+	entries []titleEntry
+	// loadedAt is zero when the index is stale
+	loadedAt time.Time
+}
+
+// titleEntry is a key a title can be completed from: its movieid, or its
+// lowercased name from the start of any of its words
+type titleEntry struct {
+	key   string
+	title MovieTitle
+	// rank puts titles whose name starts with the prefix first, then
+	// movieids, then titles with a later word starting with it
+	rank int
+}
+
+func newTitleIndex(load func(ctx context.Context) ([]MovieTitle, error)) *titleIndex {
+	return &titleIndex{load: load}
+}
+
+func titleEntries(t MovieTitle) []titleEntry {
+	name := strings.ToLower(t.MovieName)
+	entries := []titleEntry{{key: strings.ToLower(t.MovieID), title: t, rank: 1}}
+
+	spans, _ := findWords(name, nil)
+	for i, span := range spans {
+		entry := titleEntry{key: name[span.start:], title: t, rank: 2}
+		if i == 0 {
+			entry.rank = 0
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// fresh loads the index when it is stale or too old. It loads with the
+// lock held, so that a title added or removed meanwhile is applied after
+// the load rather than lost.
+func (ix *titleIndex) fresh(ctx context.Context) error {
+	ix.mu.RLock()
+	fresh := ix.isFresh()
+	ix.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.isFresh() {
+		return nil
+	}
+
+	titles, err := ix.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	ix.titles = make(map[string]MovieTitle, len(titles))
+	ix.entries = nil
+	for _, t := range titles {
+		ix.titles[t.MovieID] = t
+		ix.entries = append(ix.entries, titleEntries(t)...)
+	}
+	sort.Slice(ix.entries, func(i, j int) bool { return ix.entries[i].key < ix.entries[j].key })
+	ix.loadedAt = time.Now()
+
+	return nil
+}
+
+func (ix *titleIndex) isFresh() bool {
+	return !ix.loadedAt.IsZero() && time.Since(ix.loadedAt) < titleIndexMaxAge
+}
+
+// add puts the title of a movie in the index, replacing the one it had
+func (ix *titleIndex) add(t MovieTitle) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.loadedAt.IsZero() {
+		return
+	}
+
+	ix.removeLocked(t.MovieID)
+	ix.titles[t.MovieID] = t
+	for _, entry := range titleEntries(t) {
+		i := sort.Search(len(ix.entries), func(i int) bool { return ix.entries[i].key >= entry.key })
+		ix.entries = append(ix.entries, titleEntry{})
+		copy(ix.entries[i+1:], ix.entries[i:])
+		ix.entries[i] = entry
+	}
+}
+
+// remove takes the title of a movie out of the index
+func (ix *titleIndex) remove(movieID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if !ix.loadedAt.IsZero() {
+		ix.removeLocked(movieID)
+	}
+}
+
+func (ix *titleIndex) removeLocked(movieID string) {
+	if _, ok := ix.titles[movieID]; !ok {
+		return
+	}
+
+	delete(ix.titles, movieID)
+	entries := ix.entries[:0]
+	for _, entry := range ix.entries {
+		if entry.title.MovieID != movieID {
+			entries = append(entries, entry)
+		}
+	}
+	ix.entries = entries
+}
+
+// invalidate marks the index stale, after changes to many movies
+func (ix *titleIndex) invalidate() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.loadedAt = time.Time{}
+}
+
+// all returns every title in the index
+func (ix *titleIndex) all(ctx context.Context) ([]MovieTitle, error) {
+	if err := ix.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	titles := make([]MovieTitle, 0, len(ix.titles))
+	for _, t := range ix.titles {
+		titles = append(titles, t)
+	}
+	sort.Slice(titles, func(i, j int) bool { return titles[i].MovieID < titles[j].MovieID })
+
+	return titles, nil
+}
+
+// complete returns up to limit titles with a name, a word of the name or
+// a movieid starting with prefix, ignoring case. Titles are ranked as in
+// titleEntry, and alphabetically within a rank.
+func (ix *titleIndex) complete(ctx context.Context, prefix string, limit int) ([]MovieTitle, error) {
+	if err := ix.fresh(ctx); err != nil {
+		return nil, err
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	prefix = strings.ToLower(prefix)
+	ranks := make([][]MovieTitle, 3)
+	seen := make([]map[string]bool, 3)
+	for i := range seen {
+		seen[i] = make(map[string]bool)
+	}
+
+	// The entries starting with prefix are next to each other
+	i := sort.Search(len(ix.entries), func(i int) bool { return ix.entries[i].key >= prefix })
+	for ; i < len(ix.entries) && strings.HasPrefix(ix.entries[i].key, prefix); i++ {
+		entry := ix.entries[i]
+		if len(ranks[entry.rank]) < limit && !seen[entry.rank][entry.title.MovieID] {
+			seen[entry.rank][entry.title.MovieID] = true
+			ranks[entry.rank] = append(ranks[entry.rank], entry.title)
+		}
+	}
+
+	titles := []MovieTitle{}
+	done := make(map[string]bool)
+	for _, ranked := range ranks {
+		for _, t := range ranked {
+			if len(titles) < limit && !done[t.MovieID] {
+				done[t.MovieID] = true
+				titles = append(titles, t)
+			}
+		}
+	}
+
+	return titles, nil
+}
+
+// autocompleteMovies godoc
+// @Description Complete a movie title as it is typed. Titles whose name starts with the prefix come first, then those whose movieid does, then those with a later word that does.
+// @Description Titles are kept in memory by the server, so completing does not query the database.
+// @Produce json
+// @Param prefix query string true "What has been typed so far, matched ignoring case"
+// @Param limit query int false "Most titles to return, 10 by default and 50 at most"
+// @Success 200 {object} AutocompleteResponse{type=string,data=[]MovieTitle,message=string} "Successfully complete the prefix"
+// @Failure 400 {object} AutocompleteResponse{type=string,message=string,errors=[]ParamError} "A query parameter is missing, unknown or invalid"
+// @Failure 500 {object} AutocompleteResponse{type=string,message=string} "Fail to load the titles"
+// @Router /movies/autocomplete/ [get]
+func (s *server) autocompleteMovies(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /movies/autocomplete")
+
+	query := reader.URL.Query()
+	prefix := strings.TrimSpace(query.Get("prefix"))
+	limit := defaultAutocompleteLimit
+	var errs []ParamError
+
+	for _, param := range sortedParams(query) {
+		if param != "prefix" && param != "limit" {
+			errs = append(errs, ParamError{Param: param, Message: "Unknown parameter"})
+		}
+	}
+
+	if prefix == "" {
+		errs = append(errs, ParamError{Param: "prefix", Message: "prefix must hold what has been typed so far"})
+	}
+
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxAutocompleteLimit {
+			errs = append(errs, ParamError{Param: "limit", Message: fmt.Sprintf("limit must be a number from 1 to %d", maxAutocompleteLimit)})
+		}
+		limit = n
+	}
+
+	if len(errs) > 0 {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(AutocompleteResponse{Type: "error", Message: "Invalid query parameters", Errors: errs})
+		return
+	}
+
+	titles, err := s.titles.complete(reader.Context(), prefix, limit)
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(AutocompleteResponse{Type: "error", Message: "Failed to load the movie titles from the database"})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(AutocompleteResponse{Type: "success", Data: titles, Message: fmt.Sprintf("Found %d titles", len(titles))})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// titleIDs joins the movieids of titles with commas
+func titleIDs(titles []MovieTitle) string {
+	ids := make([]string, len(titles))
+	for i, t := range titles {
+		ids[i] = t.MovieID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestTitleIndexComplete(t *testing.T) {
+	ix := newTitleIndex(func(ctx context.Context) ([]MovieTitle, error) {
+		return []MovieTitle{
+			{MovieID: "aliens", MovieName: "Aliens"},
+			{MovieID: "factor", MovieName: "The Alien Factor"},
+			{MovieID: "alien", MovieName: "Alien"},
+			{MovieID: "alcatraz", MovieName: "Escape from Alcatraz"},
+			{MovieID: "alien3", MovieName: "Alien 3"},
+			{MovieID: "heat", MovieName: "Heat"},
+		}, nil
+	})
+
+	tests := []struct {
+		prefix string
+		limit  int
+		want   string
+	}{
+		// Names first, then movieids, then later words, each alphabetically
+		{prefix: "al", limit: 10, want: "alien,alien3,aliens,alcatraz,factor"},
+		{prefix: "ALIEN", limit: 10, want: "alien,alien3,aliens,factor"},
+		{prefix: "alien ", limit: 10, want: "alien3,factor"},
+		{prefix: "al", limit: 2, want: "alien,alien3"},
+		{prefix: "al", limit: 4, want: "alien,alien3,aliens,alcatraz"},
+		{prefix: "fac", limit: 10, want: "factor"},
+		{prefix: "3", limit: 10, want: "alien3"},
+		{prefix: "he", limit: 1, want: "heat"},
+		{prefix: "zz", limit: 10, want: ""},
+	}
+
+	for _, test := range tests {
+		titles, err := ix.complete(context.Background(), test.prefix, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := titleIDs(titles); got != test.want {
+			t.Errorf("complete(%q, %d) = %s, want %s", test.prefix, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestAutocompleteMovies(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien", "m2:Aliens", "m3:Heat")
+
+	recorder := serve(t, router, "GET", "/movies/autocomplete/?prefix=ali&limit=1", "")
+	var response AutocompleteResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusOK || titleIDs(response.Data) != "m1" || response.Data[0].MovieName != "Alien" {
+		t.Fatalf("got status %d and %+v, want Alien alone", recorder.Code, response.Data)
+	}
+
+	for _, query := range []string{"", "?prefix=+", "?prefix=ali&limit=0", "?prefix=ali&limit=51", "?prefix=ali&sort=name"} {
+		if recorder := serve(t, router, "GET", "/movies/autocomplete/"+query, ""); recorder.Code != http.StatusBadRequest {
+			t.Errorf("got status %d for %q, want 400", recorder.Code, query)
+		}
+	}
+}
+
+func TestAutocompleteFollowsChanges(t *testing.T) {
+	router := testRouter(t)
+
+	complete := func(prefix string) string {
+		t.Helper()
+		recorder := serve(t, router, "GET", "/movies/autocomplete/?prefix="+url.QueryEscape(prefix), "")
+		var response AutocompleteResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || recorder.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", recorder.Code, recorder.Body.String())
+		}
+		return titleIDs(response.Data)
+	}
+
+	// The index is loaded while empty, then kept up to date
+	if got := complete("ali"); got != "" {
+		t.Fatalf("got %s from an empty catalogue", got)
+	}
+
+	steps := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		prefix  string
+		want    string
+		without string
+	}{
+		{name: "create", method: "POST", target: "/addmovie/", body: `{"movieid":"m1","moviename":"Alien"}`, prefix: "ali", want: "m1"},
+		{name: "update", method: "PUT", target: "/updatemovie/m1/", body: `{"moviename":"Heat"}`, prefix: "hea", want: "m1", without: "ali"},
+		{name: "patch", method: "PATCH", target: "/updatemovie/m1/", body: `{"moviename":"Aliens"}`, prefix: "aliens", want: "m1", without: "hea"},
+		{name: "delete", method: "DELETE", target: "/deletemovie/m1/", prefix: "aliens", want: ""},
+		{name: "restore", method: "POST", target: "/trash/m1/restore/", prefix: "aliens", want: "m1"},
+		{name: "bulk create", method: "POST", target: "/movies/bulk/", body: `[{"movieid":"m2","moviename":"Alien 3"}]`, prefix: "alien", want: "m2,m1"},
+		{name: "second delete", method: "DELETE", target: "/deletemovie/m1/", prefix: "alien", want: "m2"},
+		{name: "purge", method: "DELETE", target: "/trash/m1/", prefix: "alien", want: "m2"},
+		{name: "create with the purged movieid", method: "POST", target: "/addmovie/", body: `{"movieid":"m1","moviename":"Alien Nation"}`, prefix: "alien", want: "m2,m1", without: "aliens"},
+	}
+
+	for _, step := range steps {
+		if recorder := serve(t, router, step.method, step.target, step.body); recorder.Code >= 300 {
+			t.Fatalf("%s: got status %d: %s", step.name, recorder.Code, recorder.Body.String())
+		}
+		if got := complete(step.prefix); got != step.want {
+			t.Errorf("after the %s, got %s for %q, want %s", step.name, got, step.prefix, step.want)
+		}
+		if step.without != "" {
+			if got := complete(step.without); got != "" {
+				t.Errorf("after the %s, got %s for the old name %q", step.name, got, step.without)
+			}
+		}
+	}
+}
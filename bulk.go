@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxBulkSize is the most movies one bulk request can hold
+const maxBulkSize = 1000
+
+// Statuses of a BulkItem
+const (
+	bulkCreated   = "created"
+	bulkDuplicate = "duplicate"
+	bulkInvalid   = "invalid"
+	// bulkSkipped is for valid movies of an all-or-nothing batch that failed
+	bulkSkipped = "skipped"
+	bulkDeleted = "deleted"
+	// bulkNotFound is for movieids that are unknown or already in the trash
+	bulkNotFound = "not_found"
+)
+
+// BulkItem reports what happened to one movie of a bulk request
+type BulkItem struct {
+	// Index is the position of the movie in the request, from 0
+	Index   int    `json:"index"`
+	MovieID string `json:"movieid,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type BulkResponse struct {
+	Type    string     `json:"type"`
+	Data    []BulkItem `json:"data"`
+	Message string     `json:"message"`
+}
+
+// parseBatchMode reads the mode of a request that creates many movies,
+// reporting whether it is atomic. Bulk creates and imports both default to
+// the atomic mode, so that a request that cannot do all it was asked does
+// nothing rather than leave part of a batch behind.
+func parseBatchMode(mode string) (bool, error) {
+	switch mode {
+	case "", "atomic":
+		return true, nil
+	case "best_effort":
+		return false, nil
+	default:
+		return false, errors.New("mode must be atomic or best_effort")
+	}
+}
+
+// createMovies godoc
+// @Description Create many movies in one request, each validated like a single created movie.
+// @Description In the atomic mode, the default, one invalid or duplicate movie means none are created. In the best_effort mode every valid movie that is not a duplicate is created.
+// @Description Either way data holds the status of every movie: created, duplicate, invalid, or skipped when a failed atomic batch held it back.
+// @Accept json
+// @Produce json
+// @Param movies body []Movie true "Movies to create, at most 1000"
+// @Param mode query string false "atomic or best_effort" Enums(atomic, best_effort)
+// @Success 201 {object} BulkResponse{type=string,data=[]BulkItem,message=string} "Every movie has been created"
+// @Success 200 {object} BulkResponse{type=string,data=[]BulkItem,message=string} "Some movies could not be created in the best_effort mode"
+// @Failure 400 {object} BulkResponse{type=string,data=[]BulkItem,message=string} "The body is not a list of movies, or an atomic batch holds an invalid movie"
+// @Failure 409 {object} BulkResponse{type=string,data=[]BulkItem,message=string} "An atomic batch holds a duplicate movie"
+// @Failure 500 {object} BulkResponse{type=string,message=string} "Fail to insert the movies"
+// @Router /movies/bulk/ [post]
+func (s *server) createMovies(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: POST /movies/bulk")
+
+	atomic, err := parseBatchMode(reader.URL.Query().Get("mode"))
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "error", Message: err.Error()})
+		return
+	}
+
+	// Movies are decoded one by one, so that one of the wrong shape is
+	// reported as invalid rather than failing the whole request
+	var raw []json.RawMessage
+	if err := json.NewDecoder(reader.Body).Decode(&raw); err != nil || len(raw) == 0 || len(raw) > maxBulkSize {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "error", Message: fmt.Sprintf("The request body must be a list of 1 to %d movies", maxBulkSize)})
+		return
+	}
+
+	items := make([]BulkItem, len(raw))
+	var movies []Movie
+	// indexes maps each movie to create back to its item
+	var indexes []int
+	for i, data := range raw {
+		items[i].Index = i
+
+		var m Movie
+		err := json.Unmarshal(data, &m)
+		if err == nil {
+			err = m.validate()
+		} else {
+			err = errors.New("Not a valid movie")
+		}
+		items[i].MovieID = m.MovieID
+
+		if err != nil {
+			items[i].Status, items[i].Message = bulkInvalid, err.Error()
+			continue
+		}
+		movies = append(movies, m)
+		indexes = append(indexes, i)
+	}
+
+	// An invalid movie fails an atomic batch before the store is involved
+	if atomic && len(movies) < len(items) {
+		for _, i := range indexes {
+			items[i].Status = bulkSkipped
+		}
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "failure", Data: items, Message: "No movie has been created, as some are invalid."})
+		return
+	}
+
+	var results []CreateResult
+	if len(movies) > 0 {
+		err = s.audited(reader, func(store Store) ([]AuditEntry, error) {
+			var err error
+			results, err = store.CreateMany(reader.Context(), movies, BatchOptions{Atomic: atomic})
+
+			var entries []AuditEntry
+			for _, result := range results {
+				if result.Err == nil {
+					entries = append(entries, auditEntry("create", result.Movie.MovieID, nil, result.Movie))
+				}
+			}
+			return entries, err
+		})
+	}
+
+	if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "error", Message: notAuditedMessage})
+		return
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "error", Message: "Failed to insert the movies"})
+		return
+	}
+
+	created := 0
+	for j, result := range results {
+		item := &items[indexes[j]]
+		switch {
+		case result.Err == nil:
+			item.Status = bulkCreated
+			created++
+			s.titles.add(result.Movie.title())
+		case errors.Is(result.Err, ErrDuplicate):
+			item.Status, item.Message = bulkDuplicate, "A movie with that movieid already exists."
+		default:
+			item.Status = bulkSkipped
+		}
+	}
+
+	var response = BulkResponse{Type: "success", Data: items, Message: fmt.Sprintf("%d of %d movies have been created.", created, len(items))}
+	if atomic && created == 0 {
+		writer.WriteHeader(http.StatusConflict)
+		response.Type, response.Message = "failure", "No movie has been created, as some already exist."
+	} else if created == len(items) {
+		writer.WriteHeader(http.StatusCreated)
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// BulkDelete is the body of a bulk delete by movieid
+type BulkDelete struct {
+	MovieIDs []string `json:"movieids"`
+}
+
+// deleteMovies godoc
+// @Description Move many movies to the trash in one transaction, either those listed in the body or those matching filters in the query string.
+// @Description The filters are those of GET /movies/, and at least one is needed. data holds the status of every movie: deleted, or not_found for movieids that are unknown or already in the trash.
+// @Accept json
+// @Produce json
+// @Param movies body BulkDelete false "The movieids to delete, at most 1000"
+// @Param moviename query string false "Only movies with exactly this name"
+// @Param name_prefix query string false "Only movies whose name starts with this, ignoring case"
+// @Param release_year query int false "Only movies released this year"
+// @Param release_year_min query int false "Only movies released this year or later"
+// @Param release_year_max query int false "Only movies released this year or earlier"
+// @Param runtime_minutes query int false "Only movies running this long"
+// @Param runtime_minutes_min query int false "Only movies running at least this long"
+// @Param runtime_minutes_max query int false "Only movies running at most this long"
+// @Param original_language query string false "Only movies in this language"
+// @Param rating query string false "Only movies with this rating"
+// @Param genre query string false "Only movies classified under the genre with this genreid"
+// @Success 200 {object} BulkResponse{type=string,data=[]BulkItem,message=string} "Successfully move the movies to the trash"
+// @Failure 400 {object} JsonResponse{type=string,message=string,errors=[]ParamError} "Neither movieids nor filters are given, or both are, or a filter is invalid"
+// @Failure 500 {object} BulkResponse{type=string,message=string} "Fail to delete the movies"
+// @Router /movies/bulk/ [delete]
+func (s *server) deleteMovies(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: DELETE /movies/bulk")
+
+	var body BulkDelete
+	if err := json.NewDecoder(reader.Body).Decode(&body); err != nil && err != io.EOF {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "error", Message: "The request body must be an object with a list of movieids"})
+		return
+	}
+
+	query := reader.URL.Query()
+	var q MovieQuery
+	var errs []ParamError
+	for _, param := range sortedParams(query) {
+		if _, ok := movieFilters[param]; !ok && param != "genre" {
+			errs = append(errs, ParamError{Param: param, Message: "Unknown parameter"})
+		}
+	}
+	errs = append(errs, parseFilters(query, &q)...)
+
+	filtered := len(q.Conditions) > 0 || q.Genre != ""
+	if len(body.MovieIDs) > 0 && filtered {
+		errs = append(errs, ParamError{Param: "movieids", Message: "Give either movieids or filters, not both"})
+	} else if len(body.MovieIDs) == 0 && !filtered && len(errs) == 0 {
+		errs = append(errs, ParamError{Param: "movieids", Message: "Give the movieids to delete or at least one filter. DELETE /deletemovies/ deletes every movie."})
+	} else if len(body.MovieIDs) > maxBulkSize {
+		errs = append(errs, ParamError{Param: "movieids", Message: fmt.Sprintf("At most %d movieids can be deleted at once", maxBulkSize)})
+	}
+
+	if len(errs) > 0 {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "Invalid bulk delete", Errors: errs})
+		return
+	}
+
+	if len(body.MovieIDs) > 0 {
+		q.Conditions = []MovieCondition{{Field: "movieid", Op: opIn, Value: body.MovieIDs}}
+	}
+
+	var deleted []Movie
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		var err error
+		deleted, err = store.DeleteMany(reader.Context(), q)
+
+		// The movies are read as the delete leaves them, so the audit log
+		// has them with the deleted_at they were trashed at
+		entries := make([]AuditEntry, len(deleted))
+		for i, m := range deleted {
+			entries[i] = auditEntry("delete", m.MovieID, m, nil)
+		}
+		return entries, err
+	})
+
+	if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "error", Message: notAuditedMessage})
+		return
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(BulkResponse{Type: "error", Message: "Failed to delete the movies"})
+		return
+	}
+
+	found := make(map[string]bool)
+	for _, m := range deleted {
+		found[m.MovieID] = true
+		s.titles.remove(m.MovieID)
+	}
+
+	// With movieids, every one is reported in the order given, and with
+	// filters every movie deleted is
+	items := []BulkItem{}
+	var notFound []string
+	if len(body.MovieIDs) > 0 {
+		for i, movieID := range body.MovieIDs {
+			item := BulkItem{Index: i, MovieID: movieID, Status: bulkDeleted}
+			if !found[movieID] {
+				item.Status = bulkNotFound
+				notFound = append(notFound, movieID)
+			}
+			items = append(items, item)
+		}
+	} else {
+		for i, m := range deleted {
+			items = append(items, BulkItem{Index: i, MovieID: m.MovieID, Status: bulkDeleted})
+		}
+	}
+
+	message := fmt.Sprintf("%d movies have been moved to the trash.", len(deleted))
+	if len(notFound) > 0 {
+		message += " Not found: " + strings.Join(notFound, ", ")
+	}
+
+	var response = BulkResponse{Type: "success", Data: items, Message: message}
+	json.NewEncoder(writer).Encode(response)
+}
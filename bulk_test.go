@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func serveBulk(t *testing.T, router http.Handler, method string, target string, body string, status int) BulkResponse {
+	t.Helper()
+
+	recorder := serve(t, router, method, target, body)
+	if recorder.Code != status {
+		t.Fatalf("got status %d, want %d: %s", recorder.Code, status, recorder.Body.String())
+	}
+
+	var response BulkResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func TestCreateMoviesInBulk(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+	batch := `[{"movieid":"m2","moviename":"Heat"},{"movieid":"m1","moviename":"Aliens"},{"movieid":"m3"}]`
+
+	// The default atomic mode fails on the invalid movie and creates nothing
+	response := serveBulk(t, router, "POST", "/movies/bulk/", batch, http.StatusBadRequest)
+	if got := bulkStatuses(response); got != "skipped skipped invalid" {
+		t.Fatalf("got statuses %s", got)
+	}
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m2/", ""), http.StatusNotFound)
+
+	response = serveBulk(t, router, "POST", "/movies/bulk/?mode=best_effort", batch, http.StatusOK)
+	if got := bulkStatuses(response); got != "created duplicate invalid" {
+		t.Fatalf("got statuses %s", got)
+	}
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m2/", ""), http.StatusOK)
+}
+
+func TestDeleteMoviesInBulk(t *testing.T) {
+	router := testRouter(t)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien","rating":"R"}`), http.StatusCreated)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m2","moviename":"Heat","rating":"R"}`), http.StatusCreated)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m3","moviename":"Up","rating":"PG"}`), http.StatusCreated)
+
+	serveBulk(t, router, "DELETE", "/movies/bulk/", "", http.StatusBadRequest)
+
+	response := serveBulk(t, router, "DELETE", "/movies/bulk/?rating=R", "", http.StatusOK)
+	if got := bulkStatuses(response); got != "deleted deleted" || response.Data[0].MovieID != "m1" || response.Data[1].MovieID != "m2" {
+		t.Fatalf("got %+v, want m1 and m2 deleted", response.Data)
+	}
+
+	response = serveBulk(t, router, "DELETE", "/movies/bulk/", `{"movieids":["m3","m1"]}`, http.StatusOK)
+	if got := bulkStatuses(response); got != "deleted not_found" {
+		t.Fatalf("got statuses %s, want m3 deleted and m1 already in the trash", got)
+	}
+
+	trash := decodeMovies(t, serve(t, router, "GET", "/trash/", ""), http.StatusOK)
+	if len(trash.Data) != 3 {
+		t.Fatalf("got %d movies in the trash, want 3", len(trash.Data))
+	}
+}
+
+func TestDeleteMoviesInBulkSparesMoviesWithoutAValue(t *testing.T) {
+	router := testRouter(t)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien","release_year":1979,"rating":"R"}`), http.StatusCreated)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m2","moviename":"Heat","rating":"R"}`), http.StatusCreated)
+	addMovies(t, router, "m3:Up")
+
+	response := serveBulk(t, router, "DELETE", "/movies/bulk/?rating=R&release_year_max=1980", "", http.StatusOK)
+	if len(response.Data) != 1 || response.Data[0].MovieID != "m1" {
+		t.Fatalf("got %+v, want m1 alone deleted", response.Data)
+	}
+
+	// Neither the movie without a year nor the one without a rating is trashed
+	list := decodeMovies(t, serve(t, router, "GET", "/movies/", ""), http.StatusOK)
+	if movieIDs(list.Data) != "m2,m3" {
+		t.Fatalf("got %s, want m2,m3 kept", movieIDs(list.Data))
+	}
+}
+
+func bulkStatuses(response BulkResponse) string {
+	var statuses string
+	for i, item := range response.Data {
+		if i > 0 {
+			statuses += " "
+		}
+		statuses += item.Status
+	}
+	return statuses
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// movieETag is the entity tag of a single movie. The version changes
+// whenever anything in the movie's JSON does, so the tag is strong. It starts
+// over when a movieid is created again, so the row ID, which is never
+// reused, keeps the tag of a new movie from matching one of the old.
+func movieETag(m Movie) string {
+	return strconv.Quote(fmt.Sprintf("%d-%d", m.ID, m.Version))
+}
+
+// listETag is the entity tag of a list of movies, built from the store's
+// fingerprint. It covers the query string too, which picks what is listed.
+func listETag(fingerprint string, query string) string {
+	sum := sha256.Sum256([]byte(fingerprint + "?" + query))
+	return strconv.Quote(hex.EncodeToString(sum[:16]))
+}
+
+// notModified sets the validators of a GET response and, when the request's
+// If-None-Match or If-Modified-Since show that the client already has this
+// version, answers 304 Not Modified and returns true. lastModified is zero
+// when the resource has no timestamp.
+func notModified(writer http.ResponseWriter, reader *http.Request, etag string, lastModified time.Time) bool {
+	writer.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		writer.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-Modified-Since is only looked at when there is no If-None-Match
+	if header := reader.Header.Get("If-None-Match"); header != "" {
+		if !ifNoneMatchHits(header, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(reader.Header.Get("If-Modified-Since"))
+		// Last-Modified only has a precision of one second
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	writer.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifNoneMatchHits evaluates an If-None-Match header against the current
+// entity tag. If-None-Match uses weak comparison, so W/ prefixes are ignored.
+func ifNoneMatchHits(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatchAllows evaluates an If-Match header against the current entity tag.
+// If-Match uses strong comparison, so weak tags never match.
+func ifMatchAllows(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchingMovie gets the movie a write applies to, checking it against the
+// If-Match header of the request. It returns ErrVersionConflict when the
+// header does not match. The write should expect the version returned, so
+// that it applies to the movie as it was read.
+func (s *server) matchingMovie(reader *http.Request, movieID string) (Movie, error) {
+	m, err := s.store.Get(reader.Context(), movieID)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	if header := reader.Header.Get("If-Match"); header != "" && !ifMatchAllows(header, movieETag(m)) {
+		return Movie{}, ErrVersionConflict
+	}
+
+	return m, nil
+}
@@ -73,7 +73,49 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Fail to create a new movie because at least one of the parameters is missing",
+                        "description": "Fail to create a new movie because a parameter is missing or invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "A movie with the specified movieid already exists",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to insert the new movie",
                         "schema": {
                             "allOf": [
                                 {
@@ -96,28 +138,99 @@ const docTemplate = `{
                 }
             }
         },
-        "/deletemovie/{movieid}/": {
-            "delete": {
-                "description": "Delete movie based on movieid",
+        "/audit/": {
+            "get": {
+                "description": "Get the audit log of changes made through the API, newest first.\nEvery change is recorded in the same transaction as the change itself, and a change that cannot be recorded is not made and fails its request with a 500.",
                 "produces": [
                     "application/json"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Movie ID",
+                        "description": "Only changes to this movie",
                         "name": "movieid",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only changes made by this actor",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only changes at or after this time, in RFC 3339",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only changes before this time, in RFC 3339",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "At most this many entries, 100 by default and 1000 at most",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully delete a movie with the specified movieid",
+                        "description": "Successfully get the audit log",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/main.JsonResponse"
+                                    "$ref": "#/definitions/main.AuditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.AuditEntry"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "A filter is invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.AuditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to get the audit log",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.AuditResponse"
                                 },
                                 {
                                     "type": "object",
@@ -136,15 +249,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/deletemovies/": {
+        "/deletemovie/{movieid}/": {
             "delete": {
-                "description": "Delete all movies from database",
+                "description": "Move a movie to the trash based on movieid. It can be restored or purged through /trash/.",
                 "produces": [
                     "application/json"
                 ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "Succesfully delete all movies",
+                        "description": "Successfully delete a movie with the specified movieid",
                         "schema": {
                             "allOf": [
                                 {
@@ -164,8 +292,29 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "500": {
-                        "description": "Fail to delete all movies",
+                    "409": {
+                        "description": "The movie was changed by another request while it was being deleted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "The movie has changed since the version in If-Match, or no longer exists",
                         "schema": {
                             "allOf": [
                                 {
@@ -188,28 +337,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/getmovie/{movieid}/": {
-            "get": {
-                "description": "Get a movie by its movieid",
+        "/deletemovies/": {
+            "delete": {
+                "description": "Permanently delete all movies from database, including the trash. The request must carry the header \"X-Confirm-Delete: all-movies\".\nThe movies are saved in a snapshot first, whose snapshotid can be used to undo the wipe through /snapshots/{snapshotid}/restore/.",
                 "produces": [
                     "application/json"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Movie ID",
-                        "name": "movieid",
-                        "in": "path",
-                        "required": true
+                        "description": "Must be all-movies, unless dry_run is set",
+                        "name": "X-Confirm-Delete",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only count the movies that would be deleted",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully get a movie with the specified movieid",
+                        "description": "Succesfully delete all movies, or count them on a dry run",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/main.JsonResponse"
+                                    "$ref": "#/definitions/main.SnapshotResponse"
                                 },
                                 {
                                     "type": "object",
@@ -217,7 +371,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/main.Movie"
+                                                "$ref": "#/definitions/main.Snapshot"
                                             }
                                         },
                                         "message": {
@@ -232,11 +386,11 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "movieid is not provided",
+                        "description": "dry_run is not a boolean",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/main.JsonResponse"
+                                    "$ref": "#/definitions/main.SnapshotResponse"
                                 },
                                 {
                                     "type": "object",
@@ -252,12 +406,33 @@ const docTemplate = `{
                             ]
                         }
                     },
-                    "404": {
-                        "description": "A movie with the specified movieid could not be found",
+                    "428": {
+                        "description": "The confirmation header is missing",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/main.JsonResponse"
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to delete all movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
                                 },
                                 {
                                     "type": "object",
@@ -276,19 +451,19 @@ const docTemplate = `{
                 }
             }
         },
-        "/movies/": {
+        "/genres/": {
             "get": {
-                "description": "Get all movies from the database",
+                "description": "Get all genres from the database",
                 "produces": [
                     "application/json"
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully get all movies",
+                        "description": "Successfully get all genres",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/main.JsonResponse"
+                                    "$ref": "#/definitions/main.GenreResponse"
                                 },
                                 {
                                     "type": "object",
@@ -296,7 +471,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/main.Movie"
+                                                "$ref": "#/definitions/main.Genre"
                                             }
                                         },
                                         "message": {
@@ -311,11 +486,11 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "Fail to get all movies",
+                        "description": "Fail to get all genres",
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/main.JsonResponse"
+                                    "$ref": "#/definitions/main.GenreResponse"
                                 },
                                 {
                                     "type": "object",
@@ -332,34 +507,3567 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "main.JsonResponse": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/main.Movie"
+            },
+            "post": {
+                "description": "Create a new genre",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "description": "Genre Data",
+                        "name": "genre",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Genre"
+                        }
                     }
-                },
-                "message": {
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "main.Movie": {
-            "type": "object",
-            "properties": {
-                "movieid": {
-                    "type": "string"
-                },
-                "moviename": {
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully create a new genre",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Genre"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "genreid or name is missing",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "A genre with the specified genreid already exists",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{genreid}/": {
+            "get": {
+                "description": "Get a genre by its genreid",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre ID",
+                        "name": "genreid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get a genre with the specified genreid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Genre"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A genre with the specified genreid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Rename a genre",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre ID",
+                        "name": "genreid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Genre Data, only the name is used",
+                        "name": "genre",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Genre"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully rename the genre",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Genre"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "name is missing",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A genre with the specified genreid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a genre and detach it from every movie",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre ID",
+                        "name": "genreid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully delete the genre",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A genre with the specified genreid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.GenreResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/getmovie/{movieid}/": {
+            "get": {
+                "description": "Get a movie by its movieid",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. movieid,moviename. Every field by default",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the copy the client already has",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the copy the client already has",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get a movie with the specified movieid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Row ID and version of the movie, to send back in If-Match or If-None-Match"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "When the movie last changed"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "The movie has not changed"
+                    },
+                    "400": {
+                        "description": "movieid is not provided, or a field is unknown",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "errors": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ParamError"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A movie with the specified movieid could not be found, with the closest movies as suggestions",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "suggestions": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.MovieTitle"
+                                            }
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/": {
+            "get": {
+                "description": "Get all movies from the database, or a page of them when limit, offset or cursor is given.\nPages can be followed through the Link header or the cursors in meta.\nWithout paging the movies are streamed as they are read, and a failure part way through cuts the response off.\nUnknown query parameters are refused, with every refused parameter listed in errors. A movie without a year, runtime, language or rating matches no filter on that field.",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Movies per page, 50 by default and 500 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Movies to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of another page, instead of offset",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, descending when prefixed with -, e.g. moviename,-id. One of id, movieid, moviename, release_year, runtime_minutes, original_language, rating",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies with exactly this name",
+                        "name": "moviename",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies whose name starts with this, ignoring case",
+                        "name": "name_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies released this year",
+                        "name": "release_year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies released this year or later",
+                        "name": "release_year_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies released this year or earlier",
+                        "name": "release_year_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies running this long",
+                        "name": "runtime_minutes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies running at least this long",
+                        "name": "runtime_minutes_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies running at most this long",
+                        "name": "runtime_minutes_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies in this language",
+                        "name": "original_language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies with this rating",
+                        "name": "rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies classified under the genre with this genreid",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. movieid,moviename. Every field by default",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the list the client already has",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get all movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "meta": {
+                                            "$ref": "#/definitions/main.ListMeta"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the list"
+                            },
+                            "Link": {
+                                "type": "string",
+                                "description": "The next and prev pages"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "No movie has changed"
+                    },
+                    "400": {
+                        "description": "A query parameter is unknown or invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "errors": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ParamError"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to get all movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/autocomplete/": {
+            "get": {
+                "description": "Complete a movie title as it is typed. Titles whose name starts with the prefix come first, then those whose movieid does, then those with a later word that does.\nTitles are kept in memory by the server, so completing does not query the database.",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "What has been typed so far, matched ignoring case",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Most titles to return, 10 by default and 50 at most",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully complete the prefix",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.AutocompleteResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.MovieTitle"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "A query parameter is missing, unknown or invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.AutocompleteResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "errors": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ParamError"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to load the titles",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.AutocompleteResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/bulk/": {
+            "post": {
+                "description": "Create many movies in one request, each validated like a single created movie.\nIn the atomic mode, the default, one invalid or duplicate movie means none are created. In the best_effort mode every valid movie that is not a duplicate is created.\nEither way data holds the status of every movie: created, duplicate, invalid, or skipped when a failed atomic batch held it back.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "description": "Movies to create, at most 1000",
+                        "name": "movies",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.Movie"
+                            }
+                        }
+                    },
+                    {
+                        "enum": [
+                            "atomic",
+                            "best_effort"
+                        ],
+                        "type": "string",
+                        "description": "atomic or best_effort",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Some movies could not be created in the best_effort mode",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.BulkResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.BulkItem"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "201": {
+                        "description": "Every movie has been created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.BulkResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.BulkItem"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "The body is not a list of movies, or an atomic batch holds an invalid movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.BulkResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.BulkItem"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "An atomic batch holds a duplicate movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.BulkResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.BulkItem"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to insert the movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.BulkResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move many movies to the trash in one transaction, either those listed in the body or those matching filters in the query string.\nThe filters are those of GET /movies/, and at least one is needed. data holds the status of every movie: deleted, or not_found for movieids that are unknown or already in the trash.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "description": "The movieids to delete, at most 1000",
+                        "name": "movies",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.BulkDelete"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies with exactly this name",
+                        "name": "moviename",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies whose name starts with this, ignoring case",
+                        "name": "name_prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies released this year",
+                        "name": "release_year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies released this year or later",
+                        "name": "release_year_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies released this year or earlier",
+                        "name": "release_year_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies running this long",
+                        "name": "runtime_minutes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies running at least this long",
+                        "name": "runtime_minutes_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only movies running at most this long",
+                        "name": "runtime_minutes_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies in this language",
+                        "name": "original_language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies with this rating",
+                        "name": "rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only movies classified under the genre with this genreid",
+                        "name": "genre",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully move the movies to the trash",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.BulkResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.BulkItem"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Neither movieids nor filters are given, or both are, or a filter is invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "errors": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ParamError"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to delete the movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.BulkResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/export/": {
+            "get": {
+                "description": "Export every movie outside the trash, in the order they were created, as CSV or as newline-delimited JSON with one movie per line.\nThe movies are streamed as they are read from the database rather than gathered first, so that the whole catalogue can be exported. A failure before the first movie is answered with a 500, and a failure after it aborts the connection, so that a cut off export cannot pass for a complete one.\nCSV columns are named after the fields, genres are given by genreid separated by semicolons, and the file can be sent back to POST /movies/import/.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "parameters": [
+                    {
+                        "enum": [
+                            "ndjson",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "csv or ndjson, ndjson by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The movies",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "The format is unknown",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "The movies could not be read",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/import/": {
+            "post": {
+                "description": "Create movies from a CSV file whose first row is a header, sent as the request body or as the file field of a multipart form.\nColumns named after a movie field are imported into it, ignoring case, spaces and dashes, as are the columns title, name, year, runtime and language. map names the field of other columns, e.g. Title:moviename,Year:release_year. Other columns are ignored.\nEvery row is validated like a single created movie. In the atomic mode, the default, one invalid or duplicate row means none are created. In the best_effort mode every valid row that is not a duplicate is created.\ndata holds the status of every row: created, duplicate, invalid, skipped when a failed atomic import held it back, or would_create in a dry run, which writes nothing.",
+                "consumes": [
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "description": "The CSV file, at most 10 MB",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Column:field pairs separated by commas",
+                        "name": "map",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "atomic",
+                            "best_effort"
+                        ],
+                        "type": "string",
+                        "description": "atomic or best_effort",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report what would be created",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A dry run, or some rows could not be created in the best_effort mode",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.ImportResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ImportRow"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "201": {
+                        "description": "Every row has been created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.ImportResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ImportRow"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "The file cannot be read or mapped, or an atomic import holds an invalid row",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.ImportResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ImportRow"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "An atomic import holds a duplicate row",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.ImportResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ImportRow"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to insert the movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.ImportResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/search/": {
+            "get": {
+                "description": "Search the names and synopses of movies, best matches first. Every word has to match, in any form, so \"alien\" also finds \"Aliens\".\nHits are wrapped in \u003cmark\u003e tags in highlights, where long synopses are cut down to the fragments around them.\nWith fuzzy=true, movie names and movieids close to q are found instead, for misspelt titles.\nWhen nothing is found, suggestions holds the closest movies.",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Words to search for",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Most results to return, 20 by default and 100 at most",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Match misspelt names and movieids instead of words",
+                        "name": "fuzzy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully search the movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SearchResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.SearchResult"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "suggestions": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.MovieTitle"
+                                            }
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "A query parameter is missing, unknown or invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SearchResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "errors": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.ParamError"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to search the movies",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SearchResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{movieid}/credits/": {
+            "get": {
+                "description": "Get the credits of a movie in billing order",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get the credits",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Credit"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A movie with the specified movieid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Credit a person on a movie as director, writer or actor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Credit Data, personid and role are required",
+                        "name": "credit",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Credit"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully add the credit",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Credit"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "A parameter is missing or invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "The movie or the person could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "The person already has this credit on the movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{movieid}/credits/{creditid}/": {
+            "delete": {
+                "description": "Remove a credit from a movie",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Credit ID",
+                        "name": "creditid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully remove the credit",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "The movie has no credit with the specified creditid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{movieid}/genres/{genreid}/": {
+            "put": {
+                "description": "Attach a genre to a movie",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Genre ID",
+                        "name": "genreid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully attach the genre, returns the updated movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "The movie or the genre could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Detach a genre from a movie",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Genre ID",
+                        "name": "genreid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully detach the genre, returns the updated movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "The movie or the genre could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/people/": {
+            "get": {
+                "description": "Get all people from the database",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get all people",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Person"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to get all people",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new person",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "description": "Person Data",
+                        "name": "person",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Person"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully create a new person",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Person"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "personid or name is missing",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "A person with the specified personid already exists",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/people/{personid}/": {
+            "get": {
+                "description": "Get a person by their personid",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Person ID",
+                        "name": "personid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get a person with the specified personid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Person"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A person with the specified personid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Rename a person",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Person ID",
+                        "name": "personid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Person Data, only the name is used",
+                        "name": "person",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Person"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully rename the person",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Person"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "name is missing",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A person with the specified personid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a person and all of their credits",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Person ID",
+                        "name": "personid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully delete the person",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A person with the specified personid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.PersonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/people/{personid}/filmography/": {
+            "get": {
+                "description": "Get every credit of a person, ordered by release year",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Person ID",
+                        "name": "personid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get the filmography",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Credit"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A person with the specified personid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.CreditResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/snapshots/": {
+            "get": {
+                "description": "Get the snapshots taken when all movies were deleted, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get all snapshots",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Snapshot"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to get all snapshots",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/snapshots/{snapshotid}/": {
+            "delete": {
+                "description": "Discard a snapshot, after which the movies in it cannot be restored",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "snapshotid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully delete the snapshot",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A snapshot with the specified snapshotid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/snapshots/{snapshotid}/restore/": {
+            "post": {
+                "description": "Undo deleting all movies by putting back the movies of a snapshot, with their genres and credits. The snapshot is used up.",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "snapshotid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully restore the snapshot",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Snapshot"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A snapshot with the specified snapshotid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "A movie in the snapshot has been created again since",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.SnapshotResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/": {
+            "get": {
+                "description": "Get the deleted movies that can still be restored, most recently deleted first",
+                "produces": [
+                    "application/json"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully get the movies in the trash",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to get the movies in the trash",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{movieid}/": {
+            "delete": {
+                "description": "Permanently delete a movie in the trash, with its genres and credits",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully purge the movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "No movie with the specified movieid is in the trash",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{movieid}/restore/": {
+            "post": {
+                "description": "Take a deleted movie out of the trash, with its genres and credits",
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully restore the movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Row ID and new version of the movie"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "No movie with the specified movieid is in the trash",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/updatemovie/{movieid}/": {
+            "put": {
+                "description": "Replace every field of a movie. Fields that are left out are cleared, genres are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Movie Data",
+                        "name": "movie",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Movie"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully replace the movie with the specified movieid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Row ID and new version of the movie"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "A parameter is missing or invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A movie with the specified movieid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "The movie was changed by another request while it was being replaced",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "The movie has changed since the version in If-Match",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to update the movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change some fields of a movie with a JSON Merge Patch (RFC 7396). Fields set to null are cleared.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie ID",
+                        "name": "movieid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.Movie"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being patched",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully patch the movie with the specified movieid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/main.Movie"
+                                            }
+                                        },
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Row ID and new version of the movie"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "The patch is malformed or leaves the movie invalid",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "A movie with the specified movieid could not be found",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "409": {
+                        "description": "The movie was changed by another request while it was being patched",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "412": {
+                        "description": "The movie has changed since the version in If-Match",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "415": {
+                        "description": "The patch is not sent as application/merge-patch+json",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Fail to update the movie",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/main.JsonResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "message": {
+                                            "type": "string"
+                                        },
+                                        "type": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "auditid": {
+                    "type": "integer"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "movieid": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AuditResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AuditEntry"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AutocompleteResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.MovieTitle"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ParamError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.BulkDelete": {
+            "type": "object",
+            "properties": {
+                "movieids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.BulkItem": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "description": "Index is the position of the movie in the request, from 0",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "movieid": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.BulkResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.BulkItem"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Credit": {
+            "type": "object",
+            "properties": {
+                "billing_order": {
+                    "description": "Position in the credits, lower comes first and 0 means unbilled",
+                    "type": "integer"
+                },
+                "character": {
+                    "description": "Name of the character played, only for actors",
+                    "type": "string"
+                },
+                "creditid": {
+                    "type": "integer"
+                },
+                "movieid": {
+                    "type": "string"
+                },
+                "moviename": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "personid": {
+                    "type": "string"
+                },
+                "role": {
+                    "description": "director, writer or actor",
+                    "type": "string"
+                }
+            }
+        },
+        "main.CreditResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Credit"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Genre": {
+            "type": "object",
+            "properties": {
+                "genreid": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.GenreResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Genre"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ImportRow"
+                    }
+                },
+                "ignored_columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ImportRow": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "movieid": {
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Row is the line the row starts on in the file, where the header is line 1",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.JsonResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Movie"
+                    }
+                },
+                "errors": {
+                    "description": "Errors lists the query parameters that were refused",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ParamError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "meta": {
+                    "description": "Meta is only set when listing movies",
+                    "$ref": "#/definitions/main.ListMeta"
+                },
+                "suggestions": {
+                    "description": "Suggestions are the closest movies when a movieid is not found",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.MovieTitle"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ListMeta": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Total is how many movies there are across every page",
+                    "type": "integer"
+                }
+            }
+        },
+        "main.Movie": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "description": "Only set on movies in the trash",
+                    "type": "string"
+                },
+                "genres": {
+                    "description": "Genres are attached and detached through /movies/{movieid}/genres/{genreid}/",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Genre"
+                    }
+                },
+                "movieid": {
+                    "type": "string"
+                },
+                "moviename": {
+                    "type": "string"
+                },
+                "original_language": {
+                    "description": "Two letter ISO 639-1 code of the original language, e.g. en",
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "Content rating: G, PG, PG-13, R, NC-17 or NR",
+                    "type": "string"
+                },
+                "release_year": {
+                    "description": "Year of the first theatrical release",
+                    "type": "integer"
+                },
+                "runtime_minutes": {
+                    "description": "Running time in minutes",
+                    "type": "integer"
+                },
+                "synopsis": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Incremented on every change. The ETag of the movie is made from it.",
+                    "type": "integer"
+                }
+            }
+        },
+        "main.MovieTitle": {
+            "type": "object",
+            "properties": {
+                "movieid": {
+                    "type": "string"
+                },
+                "moviename": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ParamError": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "param": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.Person": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "personid": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.PersonResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Person"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.SearchHighlights": {
+            "type": "object",
+            "properties": {
+                "moviename": {
+                    "type": "string"
+                },
+                "synopsis": {
+                    "description": "Synopsis is cut down to the fragments around the hits when it is long",
+                    "type": "string"
+                }
+            }
+        },
+        "main.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.SearchResult"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ParamError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "suggestions": {
+                    "description": "Suggestions are the closest movies when a search finds nothing",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.MovieTitle"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.SearchResult": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "description": "Only set on movies in the trash",
+                    "type": "string"
+                },
+                "genres": {
+                    "description": "Genres are attached and detached through /movies/{movieid}/genres/{genreid}/",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Genre"
+                    }
+                },
+                "highlights": {
+                    "$ref": "#/definitions/main.SearchHighlights"
+                },
+                "movieid": {
+                    "type": "string"
+                },
+                "moviename": {
+                    "type": "string"
+                },
+                "original_language": {
+                    "description": "Two letter ISO 639-1 code of the original language, e.g. en",
+                    "type": "string"
+                },
+                "rank": {
+                    "description": "Rank orders the results, higher first. It only compares results of the same search.",
+                    "type": "number"
+                },
+                "rating": {
+                    "description": "Content rating: G, PG, PG-13, R, NC-17 or NR",
+                    "type": "string"
+                },
+                "release_year": {
+                    "description": "Year of the first theatrical release",
+                    "type": "integer"
+                },
+                "runtime_minutes": {
+                    "description": "Running time in minutes",
+                    "type": "integer"
+                },
+                "synopsis": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Incremented on every change. The ETag of the movie is made from it.",
+                    "type": "integer"
+                }
+            }
+        },
+        "main.Snapshot": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "movie_count": {
+                    "type": "integer"
+                },
+                "snapshotid": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.SnapshotResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Snapshot"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
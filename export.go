@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFormats are the formats movies can be exported in, with their content types
+var exportFormats = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+}
+
+// exportColumns are the columns of a CSV export. They are named after the
+// fields, so that an export can be imported again.
+var exportColumns = []string{"movieid", "moviename", "release_year", "runtime_minutes", "synopsis", "original_language", "rating", "genres", "version", "updated_at"}
+
+// exportMovies writes every movie outside the trash in id order, as CSV or
+// as one JSON movie per line, calling flush every streamFlushRows movies.
+// It returns how many movies were written.
+func exportMovies(ctx context.Context, store Store, w io.Writer, format string, flush func()) (int, error) {
+	var write func(Movie) error
+	var done func() error
+	switch format {
+	case "csv":
+		table := csv.NewWriter(w)
+		if err := table.Write(exportColumns); err != nil {
+			return 0, err
+		}
+		write = func(m Movie) error { return table.Write(movieRecord(m)) }
+		done = func() error {
+			table.Flush()
+			return table.Error()
+		}
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		write = func(m Movie) error { return encoder.Encode(m) }
+		done = func() error { return nil }
+	default:
+		return 0, fmt.Errorf("unknown format %q, expected csv or ndjson", format)
+	}
+
+	count := 0
+	err := store.Stream(ctx, MovieQuery{}, func(m Movie) error {
+		if err := write(m); err != nil {
+			return err
+		}
+
+		count++
+		if count%streamFlushRows == 0 {
+			if err := done(); err != nil {
+				return err
+			}
+			flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := done(); err != nil {
+		return count, err
+	}
+	flush()
+
+	return count, nil
+}
+
+// movieRecord is a movie as a row of a CSV export, with its genres given by
+// genreid and separated by semicolons
+func movieRecord(m Movie) []string {
+	genres := make([]string, len(m.Genres))
+	for i, g := range m.Genres {
+		genres[i] = g.GenreID
+	}
+
+	return []string{
+		m.MovieID,
+		m.MovieName,
+		optionalInt(m.ReleaseYear),
+		optionalInt(m.RuntimeMinutes),
+		m.Synopsis,
+		m.OriginalLanguage,
+		m.Rating,
+		strings.Join(genres, ";"),
+		strconv.Itoa(m.Version),
+		m.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// optionalInt leaves zero out, like the JSON of a movie does
+func optionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(n)
+}
+
+// exportCatalogue godoc
+// @Description Export every movie outside the trash, in the order they were created, as CSV or as newline-delimited JSON with one movie per line.
+// @Description The movies are streamed as they are read from the database rather than gathered first, so that the whole catalogue can be exported. A failure before the first movie is answered with a 500, and a failure after it aborts the connection, so that a cut off export cannot pass for a complete one.
+// @Description CSV columns are named after the fields, genres are given by genreid separated by semicolons, and the file can be sent back to POST /movies/import/.
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv or ndjson, ndjson by default" Enums(ndjson, csv)
+// @Success 200 {string} string "The movies"
+// @Failure 400 {object} JsonResponse{type=string,message=string} "The format is unknown"
+// @Failure 500 {object} JsonResponse{type=string,message=string} "The movies could not be read"
+// @Router /movies/export/ [get]
+func (s *server) exportCatalogue(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /movies/export")
+
+	format := reader.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+
+	contentType, ok := exportFormats[format]
+	if !ok {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "format must be csv or ndjson"})
+		return
+	}
+
+	flush := func() {}
+	if flusher, ok := writer.(http.Flusher); ok {
+		flush = flusher.Flush
+	}
+
+	export := &exportWriter{writer: writer, contentType: contentType, format: format}
+	count, err := exportMovies(reader.Context(), s.store, export, format, flush)
+	if err != nil && !export.started {
+		writer.WriteHeader(http.StatusInternalServerError)
+		var response = JsonResponse{Type: "error", Message: "Failed to export the movies from the database"}
+		json.NewEncoder(writer).Encode(response)
+		return
+	} else if err != nil {
+		// The status has been sent, so the response is cut off instead of
+		// ending as if the export was complete
+		log.Println("Export failed after", count, "movies:", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	printMessage("Successfully exported all movies from DB")
+}
+
+// exportWriter sets the headers of an export with its first write, so that
+// a failure before then can still be answered with a JSON error
+type exportWriter struct {
+	writer      http.ResponseWriter
+	contentType string
+	format      string
+	started     bool
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.writer.Header().Set("Content-Type", w.contentType)
+		w.writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"movies.%s\"", w.format))
+	}
+
+	return w.writer.Write(p)
+}
+
+// runExport is the export subcommand, which exports the movies like the
+// export endpoint, to standard output unless -o names a file
+func runExport(ctx context.Context, store Store, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "ndjson", "csv or ndjson")
+	output := flags.String("o", "", "file to write the movies to instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: export [-format csv|ndjson] [-o file]")
+	}
+	if _, ok := exportFormats[*format]; !ok {
+		return fmt.Errorf("unknown format %q, expected csv or ndjson", *format)
+	}
+
+	file := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		file = f
+	}
+
+	buffered := bufio.NewWriter(file)
+	count, err := exportMovies(ctx, store, buffered, *format, func() {})
+	if err == nil {
+		err = buffered.Flush()
+	}
+	// A file that fails to close may not have been written in full
+	if err == nil && *output != "" {
+		err = file.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	// The count goes to standard error, so that it stays out of the export
+	fmt.Fprintf(os.Stderr, "Exported %d movies\n", count)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// failingStreamStore is a memory store whose Stream fails after streaming
+// its first movies
+type failingStreamStore struct {
+	*memoryStore
+	movies int
+}
+
+func (s failingStreamStore) Stream(ctx context.Context, q MovieQuery, fn func(Movie) error) error {
+	for i := 0; i < s.movies; i++ {
+		if err := fn(Movie{MovieID: "m" + strconv.Itoa(i+1), MovieName: "Alien"}); err != nil {
+			return err
+		}
+	}
+	return errors.New("the connection was lost")
+}
+
+func TestExportCSV(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien", "m2:Heat")
+
+	recorder := serve(t, router, "GET", "/movies/export/?format=csv", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get("Content-Type"); got != exportFormats["csv"] {
+		t.Fatalf("got content type %q, want %q", got, exportFormats["csv"])
+	}
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(exportColumns, ",") || records[2][1] != "Heat" {
+		t.Fatalf("got %q, want the header then Alien and Heat", records)
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		movies int
+		// aborted is whether the export has started, so that the
+		// connection is aborted rather than answered with a 500
+		aborted bool
+	}{
+		{name: "before the first movie", format: "ndjson", movies: 0},
+		{name: "before the csv is flushed", format: "csv", movies: 2},
+		{name: "after the first movie", format: "ndjson", movies: 2, aborted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRouter(newServer(failingStreamStore{newMemoryStore(), tt.movies}))
+
+			defer func() {
+				if r := recover(); tt.aborted && r != http.ErrAbortHandler {
+					t.Fatalf("got panic %v, want http.ErrAbortHandler", r)
+				} else if !tt.aborted && r != nil {
+					t.Fatalf("got panic %v, want a 500", r)
+				}
+			}()
+
+			recorder := serve(t, router, "GET", "/movies/export/?format="+tt.format, "")
+			if tt.aborted {
+				t.Fatalf("got status %d, want the export to be aborted", recorder.Code)
+			}
+			response := decodeMovies(t, recorder, http.StatusInternalServerError)
+			if response.Type != "error" {
+				t.Fatalf("got %+v, want an error", response)
+			}
+			if got := recorder.Header().Get("Content-Disposition"); got != "" {
+				t.Fatalf("got Content-Disposition %q on an error", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// selectableFields are the movie fields ?fields= can pick, in the order
+// they appear in a movie
+var selectableFields = []string{
+	"movieid", "moviename", "release_year", "runtime_minutes", "synopsis",
+	"original_language", "rating", "genres", "version", "updated_at",
+}
+
+// parseFields reads a fields parameter like "movieid,moviename". An empty
+// value selects every field, and nil is returned for it.
+func parseFields(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !contains(selectableFields, field) {
+			return nil, fmt.Errorf("Unknown field %q, use any of %s", field, strings.Join(selectableFields, ", "))
+		}
+		if !contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+// wants reports whether the field is asked for by q
+func (q MovieQuery) wants(field string) bool {
+	return q.Fields == nil || contains(q.Fields, field)
+}
+
+// MarshalJSON leaves out the movie fields that were not asked for
+func (r JsonResponse) MarshalJSON() ([]byte, error) {
+	type plain JsonResponse
+	if r.fields == nil || r.Data == nil {
+		return json.Marshal(plain(r))
+	}
+
+	data := make([]json.RawMessage, len(r.Data))
+	for i, m := range r.Data {
+		var err error
+		if data[i], err = projectMovie(m, r.fields); err != nil {
+			return nil, err
+		}
+	}
+
+	// The outer Data hides the one of plain
+	return json.Marshal(struct {
+		plain
+		Data []json.RawMessage `json:"data"`
+	}{plain(r), data})
+}
+
+// projectMovie encodes only the given fields of m. Fields that are left
+// out when empty still are.
+func projectMovie(m Movie, fields []string) (json.RawMessage, error) {
+	full, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(full, &values); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for _, field := range selectableFields {
+		value, ok := values[field]
+		if !ok || !contains(fields, field) {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%q:%s", field, value)
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
+
+// getMovieFields gets a movie, reading only the given fields from the
+// store when there are some
+func (s *server) getMovieFields(ctx context.Context, movieID string, fields []string) (Movie, error) {
+	if fields == nil {
+		return s.store.Get(ctx, movieID)
+	}
+
+	movies, err := s.store.List(ctx, MovieQuery{
+		Conditions: []MovieCondition{{Field: "movieid", Op: opEqual, Value: movieID}},
+		Fields:     fields,
+	})
+	if err != nil {
+		return Movie{}, err
+	}
+	if len(movies) == 0 {
+		return Movie{}, ErrNotFound
+	}
+
+	return movies[0], nil
+}
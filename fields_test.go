@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// dataFields returns the fields of every movie in the data of a response,
+// sorted and joined with commas
+func dataFields(t *testing.T, recorder *httptest.ResponseRecorder) []string {
+	t.Helper()
+
+	var response struct {
+		Data []map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("the response is not JSON: %v: %s", err, recorder.Body.String())
+	}
+
+	fields := make([]string, len(response.Data))
+	for i, m := range response.Data {
+		var names []string
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fields[i] = strings.Join(names, ",")
+	}
+	return fields
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: ""},
+		{value: "movieid,moviename", want: "movieid,moviename"},
+		{value: " rating , movieid,rating", want: "rating,movieid"},
+		{value: "movieid,directr", wantErr: true},
+		{value: "deleted_at", wantErr: true},
+		{value: "movieid,", wantErr: true},
+	}
+
+	for _, test := range tests {
+		fields, err := parseFields(test.value)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseFields(%q) = %v, want an error", test.value, fields)
+			}
+			continue
+		}
+		if err != nil || strings.Join(fields, ",") != test.want {
+			t.Errorf("parseFields(%q) = %v, %v, want %s", test.value, fields, err, test.want)
+		}
+	}
+}
+
+func TestFieldsProjectMovies(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return newMemoryStore() },
+		"sqlite": func(t *testing.T) Store { return testSQLiteStore(t) },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			router := newRouter(newServer(newStore(t)))
+			decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien","release_year":1979,"rating":"R"}`), http.StatusCreated)
+			decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m2","moviename":"Heat","release_year":1995}`), http.StatusCreated)
+			decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m3","moviename":"Jaws","release_year":1975}`), http.StatusCreated)
+
+			recorder := serve(t, router, "GET", "/getmovie/m1/?fields=moviename,rating", "")
+			if got := dataFields(t, recorder); recorder.Code != http.StatusOK || len(got) != 1 || got[0] != "moviename,rating" {
+				t.Fatalf("got status %d and fields %v, want moviename and rating", recorder.Code, got)
+			}
+
+			// Like every field, one asked for is left out when it is empty
+			recorder = serve(t, router, "GET", "/getmovie/m2/?fields=moviename,rating", "")
+			if got := dataFields(t, recorder); recorder.Code != http.StatusOK || len(got) != 1 || got[0] != "moviename" {
+				t.Fatalf("got status %d and fields %v, want moviename alone", recorder.Code, got)
+			}
+
+			recorder = serve(t, router, "GET", "/movies/?fields=movieid,genres", "")
+			if got := strings.Join(dataFields(t, recorder), " "); recorder.Code != http.StatusOK || got != "genres,movieid genres,movieid genres,movieid" {
+				t.Fatalf("got status %d and fields %s, want movieid and genres for every movie", recorder.Code, got)
+			}
+
+			recorder = serve(t, router, "GET", "/movies/?limit=2&fields=moviename", "")
+			if got := strings.Join(dataFields(t, recorder), " "); recorder.Code != http.StatusOK || got != "moviename moviename" {
+				t.Fatalf("got status %d and fields %s, want moviename on a page", recorder.Code, got)
+			}
+
+			for _, target := range []string{"/getmovie/m1/?fields=moviename,directr", "/movies/?fields=moviename,directr", "/movies/?limit=2&fields=deleted_at"} {
+				response := decodeMovies(t, serve(t, router, "GET", target, ""), http.StatusBadRequest)
+				if len(response.Errors) != 1 || response.Errors[0].Param != "fields" {
+					t.Fatalf("got errors %+v for %s, want one on fields", response.Errors, target)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldsKeepETags(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+
+	// The ETag of a movie does not depend on the fields it is read with
+	etag := serve(t, router, "GET", "/getmovie/m1/", "").Header().Get("ETag")
+	recorder := serve(t, router, "GET", "/getmovie/m1/?fields=moviename", "")
+	if got := recorder.Header().Get("ETag"); got != etag {
+		t.Fatalf("got ETag %q with fields, want %q", got, etag)
+	}
+	if recorder := serve(t, router, "GET", "/getmovie/m1/?fields=moviename", "", "If-None-Match", etag); recorder.Code != http.StatusNotModified {
+		t.Fatalf("got status %d, want 304", recorder.Code)
+	}
+	decodeMovies(t, serve(t, router, "PUT", "/updatemovie/m1/?fields=moviename", `{"moviename":"Aliens"}`, "If-Match", etag), http.StatusOK)
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/?fields=moviename", "", "If-None-Match", etag), http.StatusOK)
+
+	// A list is tagged along with its fields, which change its body
+	listETag := serve(t, router, "GET", "/movies/?fields=moviename", "").Header().Get("ETag")
+	if listETag == "" || listETag == serve(t, router, "GET", "/movies/", "").Header().Get("ETag") {
+		t.Fatalf("got list ETag %q, want one that differs from the list of every field", listETag)
+	}
+	if recorder := serve(t, router, "GET", "/movies/?fields=moviename", "", "If-None-Match", listETag); recorder.Code != http.StatusNotModified {
+		t.Fatalf("got status %d for the list, want 304", recorder.Code)
+	}
+}
+
+func TestFieldsKeepCursors(t *testing.T) {
+	router := testRouter(t)
+	for _, body := range []string{
+		`{"movieid":"m1","moviename":"Heat","release_year":1995}`,
+		`{"movieid":"m2","moviename":"Alien","release_year":1979}`,
+		`{"movieid":"m3","moviename":"Jaws","release_year":1975}`,
+		`{"movieid":"m4","moviename":"Fargo","release_year":1996}`,
+		`{"movieid":"m5","moviename":"Brazil","release_year":1985}`,
+	} {
+		decodeMovies(t, serve(t, router, "POST", "/addmovie/", body), http.StatusCreated)
+	}
+
+	// The sort field is not among those returned, yet still makes the cursor
+	var pages []string
+	target := "/movies/?limit=2&sort=-release_year&fields=moviename"
+	for target != "" {
+		response := decodeMovies(t, serve(t, router, "GET", target, ""), http.StatusOK)
+		var names []string
+		for _, m := range response.Data {
+			if m.MovieID != "" || m.ReleaseYear != 0 {
+				t.Fatalf("got %+v, want the name alone", m)
+			}
+			names = append(names, m.MovieName)
+		}
+		pages = append(pages, strings.Join(names, ","))
+
+		target = ""
+		if response.Meta.NextCursor != "" {
+			target = "/movies/?limit=2&sort=-release_year&fields=moviename&cursor=" + response.Meta.NextCursor
+		}
+	}
+
+	if got := strings.Join(pages, " "); got != "Fargo,Heat Brazil,Alien Jaws" {
+		t.Fatalf("got pages %s, want the movies newest first", got)
+	}
+}
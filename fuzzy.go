@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// fuzzyThreshold is the lowest similarity that counts as a fuzzy match
+	fuzzyThreshold = 0.6
+	maxSuggestions = 5
+)
+
+// MovieTitle names a movie, as suggested when a lookup or a search misses
+type MovieTitle struct {
+	MovieID   string `json:"movieid"`
+	MovieName string `json:"moviename"`
+}
+
+func (m Movie) title() MovieTitle {
+	return MovieTitle{MovieID: m.MovieID, MovieName: m.MovieName}
+}
+
+// fuzzyMatch is how closely a movie title matches some text
+type fuzzyMatch struct {
+	title MovieTitle
+	score float64
+	// start and end are the bytes of the moviename that matched, or both 0
+	// when the movieid matched best
+	start, end int
+}
+
+// fuzzyMatches returns the titles similar to text, best first. Suggestions
+// are scored here rather than in the database, because trigram similarity
+// alone misses short typos like "alein" that edit distance catches.
+func fuzzyMatches(text string, titles []MovieTitle) []fuzzyMatch {
+	var matches []fuzzyMatch
+	for _, t := range titles {
+		match := fuzzyMatch{title: t}
+		match.score, match.start, match.end = similarity(text, t.MovieName)
+		if score, _, _ := similarity(text, t.MovieID); score > match.score {
+			match.score, match.start, match.end = score, 0, 0
+		}
+
+		if match.score >= fuzzyThreshold {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	return matches
+}
+
+// suggest returns the titles closest to text, for "did you mean" hints.
+// Suggestions are only a hint, so they are left out when they cannot be had.
+func (s *server) suggest(ctx context.Context, text string) []MovieTitle {
+	titles, err := s.titles.all(ctx)
+	if err != nil {
+		return nil
+	}
+
+	var suggestions []MovieTitle
+	for _, match := range fuzzyMatches(text, titles) {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, match.title)
+	}
+
+	return suggestions
+}
+
+// similarity scores how close text is to target, from 0 to 1 ignoring case.
+// Text is also compared to every run of as many words in target, so that a
+// misspelt word still finds a longer title. It returns the bytes of target
+// that matched best.
+func similarity(text, target string) (float64, int, int) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	best, start, end := stringSimilarity(text, strings.ToLower(target)), 0, len(target)
+
+	spans, _ := findWords(target, nil)
+	n := len(strings.FieldsFunc(text, func(r rune) bool { return !isWordRune(r) }))
+	for i := 0; n > 0 && i+n <= len(spans); i++ {
+		from, to := spans[i].start, spans[i+n-1].end
+		if score := stringSimilarity(text, strings.ToLower(target[from:to])); score > best {
+			best, start, end = score, from, to
+		}
+	}
+
+	return best, start, end
+}
+
+// stringSimilarity is the better of trigram similarity and edit similarity
+func stringSimilarity(a, b string) float64 {
+	score := trigramSimilarity(a, b)
+	longest := utf8.RuneCountInString(a)
+	if n := utf8.RuneCountInString(b); n > longest {
+		longest = n
+	}
+	if longest > 0 {
+		if edit := 1 - float64(editDistance(a, b))/float64(longest); edit > score {
+			score = edit
+		}
+	}
+	return score
+}
+
+// trigramSimilarity works like similarity of the Postgres pg_trgm module:
+// the share of trigrams two strings have in common, where each word is
+// padded with two spaces in front and one behind
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !isWordRune(r) }) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// the fewest insertions, deletions, substitutions and swaps of adjacent
+// runes turning one into the other
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// Three rows of the distance matrix are enough to allow for swaps
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	row := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			row[j] = prev[j-1] + cost
+			if prev[j]+1 < row[j] {
+				row[j] = prev[j] + 1
+			}
+			if row[j-1]+1 < row[j] {
+				row[j] = row[j-1] + 1
+			}
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && prev2[j-2]+1 < row[j] {
+				row[j] = prev2[j-2] + 1
+			}
+		}
+		prev2, prev, row = prev, row, prev2
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"alien", "alien", 0},
+		{"", "alien", 5},
+		{"alien", "", 5},
+		{"alien", "alines", 2},
+		{"alien", "alein", 1},
+		{"ca", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"heat", "hat", 1},
+		{"amélie", "amelie", 1},
+		{"amélie", "aémlie", 1},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := editDistance(test.b, test.a); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		text, target string
+		want         float64
+		// matched is the part of target that matched best
+		matched string
+	}{
+		{"alien", "Alien", 1, "Alien"},
+		{"  ALIEN ", "Alien", 1, "Alien"},
+		{"alein", "Alien", 0.8, "Alien"},
+		{"godfather", "The Godfather Part II", 1, "Godfather"},
+		{"godfathr part", "The Godfather Part II", 1 - 1.0/14, "Godfather Part"},
+	}
+
+	for _, test := range tests {
+		score, start, end := similarity(test.text, test.target)
+		if math.Abs(score-test.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %v, want %v", test.text, test.target, score, test.want)
+		}
+		if got := test.target[start:end]; got != test.matched {
+			t.Errorf("similarity(%q, %q) matched %q, want %q", test.text, test.target, got, test.matched)
+		}
+	}
+
+	if score, _, _ := similarity("heat", "Alien"); score >= fuzzyThreshold {
+		t.Errorf("similarity(heat, Alien) = %v, want it below the threshold", score)
+	}
+}
+
+func TestFuzzyMatches(t *testing.T) {
+	titles := []MovieTitle{
+		{MovieID: "heat", MovieName: "Heat"},
+		{MovieID: "aliens", MovieName: "Aliens"},
+		{MovieID: "alien", MovieName: "Alien"},
+		{MovieID: "tt0078748", MovieName: "Nostromo"},
+	}
+
+	matches := fuzzyMatches("alein", titles)
+	if len(matches) != 2 || matches[0].title.MovieID != "alien" || matches[1].title.MovieID != "aliens" {
+		t.Fatalf("got %+v, want alien then aliens", matches)
+	}
+
+	// A movieid matches too, with nothing in the name to highlight
+	matches = fuzzyMatches("tt0078784", titles)
+	if len(matches) != 1 || matches[0].title.MovieName != "Nostromo" || matches[0].end != 0 {
+		t.Fatalf("got %+v, want Nostromo by its movieid", matches)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Genre struct {
+	ID      int    `json:"-"`
+	GenreID string `json:"genreid"`
+	Name    string `json:"name"`
+}
+
+type GenreResponse struct {
+	Type    string  `json:"type"`
+	Data    []Genre `json:"data"`
+	Message string  `json:"message"`
+}
+
+// listGenres godoc
+// @Description Get all genres from the database
+// @Produce json
+// @Success 200 {object} GenreResponse{type=string,data=[]Genre,message=string} "Successfully get all genres"
+// @Failure 500 {object} GenreResponse{type=string,message=string} "Fail to get all genres"
+// @Router /genres/ [get]
+func (s *server) listGenres(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /genres")
+
+	genres, err := s.store.ListGenres(reader.Context())
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(GenreResponse{Type: "error", Message: "Failed to get all genres from the database"})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(GenreResponse{Type: "success", Data: genres, Message: "Successfully got all genres from DB"})
+}
+
+// getGenre godoc
+// @Description Get a genre by its genreid
+// @Produce json
+// @Param genreid path string true "Genre ID"
+// @Success 200 {object} GenreResponse{type=string,data=[]Genre,message=string} "Successfully get a genre with the specified genreid"
+// @Failure 404 {object} GenreResponse{type=string,message=string} "A genre with the specified genreid could not be found"
+// @Router /genres/{genreid}/ [get]
+func (s *server) getGenre(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /genres/{genreid}")
+
+	g, err := s.store.GetGenre(reader.Context(), mux.Vars(reader)["genreid"])
+
+	var response = GenreResponse{}
+
+	if errors.Is(err, ErrGenreNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = GenreResponse{Type: "failure", Message: "A genre with that genreid does not exist."}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = GenreResponse{Type: "error", Message: "Failed to get the genre from the database"}
+	} else {
+		response = GenreResponse{Type: "success", Data: []Genre{g}, Message: "Successfully got genre from DB"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// createGenre godoc
+// @Description Create a new genre
+// @Accept json
+// @Produce json
+// @Param genre body Genre true "Genre Data"
+// @Success 201 {object} GenreResponse{type=string,data=[]Genre,message=string} "Successfully create a new genre"
+// @Failure 400 {object} GenreResponse{type=string,message=string} "genreid or name is missing"
+// @Failure 409 {object} GenreResponse{type=string,message=string} "A genre with the specified genreid already exists"
+// @Router /genres/ [post]
+func (s *server) createGenre(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: POST /genres")
+
+	var g Genre
+	if err := json.NewDecoder(reader.Body).Decode(&g); err != nil || g.GenreID == "" || g.Name == "" {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(GenreResponse{Type: "error", Message: "You are missing genreid or name"})
+		return
+	}
+
+	var response = GenreResponse{}
+
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		var err error
+		g, err = store.CreateGenre(reader.Context(), g)
+		return []AuditEntry{auditEntry("create_genre", "", nil, g)}, err
+	})
+
+	if errors.Is(err, ErrDuplicate) {
+		writer.WriteHeader(http.StatusConflict)
+		response = GenreResponse{Type: "error", Message: "A genre with that genreid already exists."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = GenreResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = GenreResponse{Type: "error", Message: "Failed to insert a new genre"}
+	} else {
+		writer.WriteHeader(http.StatusCreated)
+		response = GenreResponse{Type: "success", Data: []Genre{g}, Message: "The genre has been inserted successfully!"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// updateGenre godoc
+// @Description Rename a genre
+// @Accept json
+// @Produce json
+// @Param genreid path string true "Genre ID"
+// @Param genre body Genre true "Genre Data, only the name is used"
+// @Success 200 {object} GenreResponse{type=string,data=[]Genre,message=string} "Successfully rename the genre"
+// @Failure 400 {object} GenreResponse{type=string,message=string} "name is missing"
+// @Failure 404 {object} GenreResponse{type=string,message=string} "A genre with the specified genreid could not be found"
+// @Router /genres/{genreid}/ [put]
+func (s *server) updateGenre(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: PUT /genres/{genreid}")
+
+	genreID := mux.Vars(reader)["genreid"]
+
+	var g Genre
+	if err := json.NewDecoder(reader.Body).Decode(&g); err != nil || g.Name == "" {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(GenreResponse{Type: "error", Message: "You are missing the genre name"})
+		return
+	}
+
+	// The genreid identifies the genre and cannot be changed
+	if g.GenreID != "" && g.GenreID != genreID {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(GenreResponse{Type: "error", Message: "The genreid in the body does not match the URL"})
+		return
+	}
+
+	var response = GenreResponse{}
+
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		// The genre is read first for the audit log
+		before, err := store.GetGenre(reader.Context(), genreID)
+		if err != nil {
+			return nil, err
+		}
+
+		g, err = store.RenameGenre(reader.Context(), genreID, g.Name)
+		return []AuditEntry{auditEntry("rename_genre", "", before, g)}, err
+	})
+
+	if errors.Is(err, ErrGenreNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = GenreResponse{Type: "failure", Message: "A genre with that genreid does not exist."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = GenreResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = GenreResponse{Type: "error", Message: "Failed to update the genre"}
+	} else {
+		response = GenreResponse{Type: "success", Data: []Genre{g}, Message: "The genre has been updated successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// deleteGenre godoc
+// @Description Delete a genre and detach it from every movie
+// @Produce json
+// @Param genreid path string true "Genre ID"
+// @Success 200 {object} GenreResponse{type=string,message=string} "Successfully delete the genre"
+// @Failure 404 {object} GenreResponse{type=string,message=string} "A genre with the specified genreid could not be found"
+// @Router /genres/{genreid}/ [delete]
+func (s *server) deleteGenre(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: DELETE /genres/{genreid}")
+
+	genreID := mux.Vars(reader)["genreid"]
+
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		// The genre is read first for the audit log
+		before, err := store.GetGenre(reader.Context(), genreID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = store.DeleteGenre(reader.Context(), genreID)
+		return []AuditEntry{auditEntry("delete_genre", "", before, nil)}, err
+	})
+
+	var response = GenreResponse{}
+
+	if errors.Is(err, ErrGenreNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = GenreResponse{Type: "failure", Message: "A genre with that genreid does not exist."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = GenreResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = GenreResponse{Type: "error", Message: "Failed to delete the genre"}
+	} else {
+		response = GenreResponse{Type: "success", Message: "The genre has been deleted successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// attachGenre godoc
+// @Description Attach a genre to a movie
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Param genreid path string true "Genre ID"
+// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully attach the genre, returns the updated movie"
+// @Failure 404 {object} JsonResponse{type=string,message=string} "The movie or the genre could not be found"
+// @Router /movies/{movieid}/genres/{genreid}/ [put]
+func (s *server) attachGenre(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: PUT /movies/{movieid}/genres/{genreid}")
+	params := mux.Vars(reader)
+
+	s.changeGenres(writer, reader, "attach_genre", params["movieid"], func(store Store) error {
+		return store.AttachGenre(reader.Context(), params["movieid"], params["genreid"])
+	}, "The genre has been attached to the movie.")
+}
+
+// detachGenre godoc
+// @Description Detach a genre from a movie
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Param genreid path string true "Genre ID"
+// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully detach the genre, returns the updated movie"
+// @Failure 404 {object} JsonResponse{type=string,message=string} "The movie or the genre could not be found"
+// @Router /movies/{movieid}/genres/{genreid}/ [delete]
+func (s *server) detachGenre(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: DELETE /movies/{movieid}/genres/{genreid}")
+	params := mux.Vars(reader)
+
+	s.changeGenres(writer, reader, "detach_genre", params["movieid"], func(store Store) error {
+		return store.DetachGenre(reader.Context(), params["movieid"], params["genreid"])
+	}, "The genre has been detached from the movie.")
+}
+
+// Attach or detach a genre through change, record it in the audit log with
+// the movie before and after, and respond with the movie as it is now
+func (s *server) changeGenres(writer http.ResponseWriter, reader *http.Request, action string, movieID string, change func(store Store) error, message string) {
+	var m Movie
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		// The movie is read first for the audit log
+		before, err := store.Get(reader.Context(), movieID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := change(store); err != nil {
+			return nil, err
+		}
+
+		m, err = store.Get(reader.Context(), movieID)
+		return []AuditEntry{auditEntry(action, movieID, before, m)}, err
+	})
+
+	var response = JsonResponse{Type: "success", Data: []Movie{m}, Message: message}
+
+	if errors.Is(err, ErrNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = JsonResponse{Type: "failure", Message: "A movie with that movieid does not exist."}
+	} else if errors.Is(err, ErrGenreNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = JsonResponse{Type: "failure", Message: "A genre with that genreid does not exist."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: "Failed to update the genres of the movie"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
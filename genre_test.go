@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// decodeGenres decodes a GenreResponse, failing the test when the status is not the expected one
+func decodeGenres(t *testing.T, recorder *httptest.ResponseRecorder, status int) GenreResponse {
+	t.Helper()
+
+	if recorder.Code != status {
+		t.Fatalf("got status %d, want %d: %s", recorder.Code, status, recorder.Body.String())
+	}
+
+	var response GenreResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("the response is not a GenreResponse: %v: %s", err, recorder.Body.String())
+	}
+	return response
+}
+
+// genreIDs joins the genreids of genres with commas
+func genreIDs(genres []Genre) string {
+	var ids string
+	for i, g := range genres {
+		if i > 0 {
+			ids += ","
+		}
+		ids += g.GenreID
+	}
+	return ids
+}
+
+func TestGenreCRUD(t *testing.T) {
+	router := testRouter(t)
+
+	decodeGenres(t, serve(t, router, "POST", "/genres/", `{"genreid":"scifi","name":"Science fiction"}`), http.StatusCreated)
+	decodeGenres(t, serve(t, router, "POST", "/genres/", `{"genreid":"horror","name":"Horror"}`), http.StatusCreated)
+	decodeGenres(t, serve(t, router, "POST", "/genres/", `{"genreid":"scifi","name":"Sci-fi"}`), http.StatusConflict)
+	decodeGenres(t, serve(t, router, "POST", "/genres/", `{"genreid":"drama"}`), http.StatusBadRequest)
+
+	// Genres are listed by name
+	response := decodeGenres(t, serve(t, router, "GET", "/genres/", ""), http.StatusOK)
+	if got := genreIDs(response.Data); got != "horror,scifi" {
+		t.Fatalf("got genres %s, want horror,scifi", got)
+	}
+
+	response = decodeGenres(t, serve(t, router, "PUT", "/genres/scifi/", `{"name":"Sci-fi"}`), http.StatusOK)
+	if response.Data[0].Name != "Sci-fi" {
+		t.Fatalf("got %+v, want the genre renamed Sci-fi", response.Data[0])
+	}
+	response = decodeGenres(t, serve(t, router, "GET", "/genres/scifi/", ""), http.StatusOK)
+	if response.Data[0].Name != "Sci-fi" {
+		t.Fatalf("got %+v after the rename, want Sci-fi", response.Data[0])
+	}
+	decodeGenres(t, serve(t, router, "PUT", "/genres/scifi/", `{"genreid":"horror","name":"Sci-fi"}`), http.StatusBadRequest)
+	decodeGenres(t, serve(t, router, "PUT", "/genres/nope/", `{"name":"Nope"}`), http.StatusNotFound)
+
+	decodeGenres(t, serve(t, router, "DELETE", "/genres/horror/", ""), http.StatusOK)
+	decodeGenres(t, serve(t, router, "GET", "/genres/horror/", ""), http.StatusNotFound)
+	decodeGenres(t, serve(t, router, "DELETE", "/genres/horror/", ""), http.StatusNotFound)
+}
+
+func TestAttachAndDetachGenre(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+	decodeGenres(t, serve(t, router, "POST", "/genres/", `{"genreid":"scifi","name":"Science fiction"}`), http.StatusCreated)
+	decodeGenres(t, serve(t, router, "POST", "/genres/", `{"genreid":"horror","name":"Horror"}`), http.StatusCreated)
+
+	decodeMovies(t, serve(t, router, "PUT", "/movies/m1/genres/scifi/", ""), http.StatusOK)
+	response := decodeMovies(t, serve(t, router, "PUT", "/movies/m1/genres/horror/", ""), http.StatusOK)
+	if m := response.Data[0]; genreIDs(m.Genres) != "horror,scifi" || m.Version != 3 {
+		t.Fatalf("got %+v, want horror and scifi at version 3", m)
+	}
+
+	// Attaching a genre twice changes nothing
+	response = decodeMovies(t, serve(t, router, "PUT", "/movies/m1/genres/scifi/", ""), http.StatusOK)
+	if m := response.Data[0]; genreIDs(m.Genres) != "horror,scifi" || m.Version != 3 {
+		t.Fatalf("got %+v after attaching scifi again, want it unchanged", m)
+	}
+
+	response = decodeMovies(t, serve(t, router, "DELETE", "/movies/m1/genres/horror/", ""), http.StatusOK)
+	if got := genreIDs(response.Data[0].Genres); got != "scifi" {
+		t.Fatalf("got genres %s after the detach, want scifi", got)
+	}
+
+	// A renamed genre is renamed on its movies, and a deleted one detached
+	decodeGenres(t, serve(t, router, "PUT", "/genres/scifi/", `{"name":"Sci-fi"}`), http.StatusOK)
+	response = decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", ""), http.StatusOK)
+	if genres := response.Data[0].Genres; len(genres) != 1 || genres[0].Name != "Sci-fi" {
+		t.Fatalf("got genres %+v, want Sci-fi", genres)
+	}
+	decodeGenres(t, serve(t, router, "DELETE", "/genres/scifi/", ""), http.StatusOK)
+	response = decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", ""), http.StatusOK)
+	if genres := response.Data[0].Genres; len(genres) != 0 {
+		t.Fatalf("got genres %+v, want none once scifi is deleted", genres)
+	}
+}
+
+func TestAttachGenreToUnknownMovieOrGenre(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+	decodeGenres(t, serve(t, router, "POST", "/genres/", `{"genreid":"scifi","name":"Science fiction"}`), http.StatusCreated)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		message string
+	}{
+		{"attach to an unknown movie", "PUT", "/movies/nope/genres/scifi/", "A movie with that movieid does not exist."},
+		{"attach an unknown genre", "PUT", "/movies/m1/genres/nope/", "A genre with that genreid does not exist."},
+		{"detach from an unknown movie", "DELETE", "/movies/nope/genres/scifi/", "A movie with that movieid does not exist."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := decodeMovies(t, serve(t, router, test.method, test.target, ""), http.StatusNotFound)
+			if response.Message != test.message {
+				t.Fatalf("got message %q, want %q", response.Message, test.message)
+			}
+		})
+	}
+
+	response := decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", ""), http.StatusOK)
+	if m := response.Data[0]; len(m.Genres) != 0 || m.Version != 1 {
+		t.Fatalf("got %+v, want the movie untouched", m)
+	}
+}
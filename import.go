@@ -0,0 +1,499 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// maxImportSize is the largest CSV file the API takes, in bytes. The import
+// subcommand has no limit.
+const maxImportSize = 10 << 20
+
+// Statuses of an ImportRow, besides those of a BulkItem
+const (
+	// importWouldCreate is for the rows a dry run would have created
+	importWouldCreate = "would_create"
+)
+
+// importableFields are the Movie fields a CSV column can be mapped to
+var importableFields = []string{"movieid", "moviename", "release_year", "runtime_minutes", "synopsis", "original_language", "rating"}
+
+// importAliases map headers that spreadsheets commonly use to the Movie
+// fields, after they have been normalized by headerKey
+var importAliases = map[string]string{
+	"movie_id": "movieid",
+	"name":     "moviename",
+	"title":    "moviename",
+	"year":     "release_year",
+	"runtime":  "runtime_minutes",
+	"language": "original_language",
+}
+
+// ImportRow reports what happened to one row of a CSV import
+type ImportRow struct {
+	// Row is the line the row starts on in the file, where the header is line 1
+	Row     int    `json:"row"`
+	MovieID string `json:"movieid,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type ImportResponse struct {
+	Type           string      `json:"type"`
+	Data           []ImportRow `json:"data"`
+	IgnoredColumns []string    `json:"ignored_columns,omitempty"`
+	Message        string      `json:"message"`
+}
+
+// importOptions are the choices shared by the import endpoint and subcommand
+type importOptions struct {
+	// mapping maps CSV columns to Movie fields, on top of the columns named
+	// after a field or one of importAliases
+	mapping map[string]string
+	atomic  bool
+	dryRun  bool
+}
+
+// csvImport is a CSV file read into movies. Rows that are not valid movies
+// are reported right away, the others are reported once they are written.
+type csvImport struct {
+	rows    []ImportRow
+	movies  []Movie
+	indexes []int
+	ignored []string
+}
+
+// parseImportMapping parses a mapping written as column:field pairs
+// separated by commas, e.g. "Title:moviename,Year:release_year"
+func parseImportMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		i := strings.LastIndex(pair, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not a column:field pair", pair)
+		}
+
+		column, field := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if !contains(importableFields, field) {
+			return nil, fmt.Errorf("%q is not a field movies can be imported into, expected one of %s", field, strings.Join(importableFields, ", "))
+		}
+		if _, ok := mapping[column]; ok {
+			return nil, fmt.Errorf("column %q is mapped twice", column)
+		}
+		mapping[column] = field
+	}
+
+	return mapping, nil
+}
+
+// headerKey normalizes a header so that "Release Year" matches release_year
+func headerKey(header string) string {
+	key := strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+}
+
+// mapColumns gives the Movie field of every column of the header, or "" for
+// the columns that are ignored
+func mapColumns(header []string, mapping map[string]string) ([]string, []string, error) {
+	for column := range mapping {
+		if !contains(header, column) {
+			return nil, nil, fmt.Errorf("the mapping names column %q, which is not in the header", column)
+		}
+	}
+
+	fields := make([]string, len(header))
+	var ignored []string
+	// columns remembers which column each field is read from
+	columns := make(map[string]string)
+	for i, column := range header {
+		field, ok := mapping[column]
+		if !ok {
+			field = headerKey(column)
+			if alias, ok := importAliases[field]; ok {
+				field = alias
+			}
+		}
+
+		if !contains(importableFields, field) {
+			ignored = append(ignored, column)
+			continue
+		}
+		if other, ok := columns[field]; ok {
+			return nil, nil, fmt.Errorf("columns %q and %q both map to %s", other, column, field)
+		}
+		columns[field] = column
+		fields[i] = field
+	}
+
+	for _, field := range []string{"movieid", "moviename"} {
+		if _, ok := columns[field]; !ok {
+			return nil, nil, fmt.Errorf("no column maps to %s, which every movie needs", field)
+		}
+	}
+
+	return fields, ignored, nil
+}
+
+// readImport reads a CSV file whose first row is a header, and validates
+// every row like createMovie validates a movie. The error is for files that
+// cannot be read at all.
+func readImport(r io.Reader, mapping map[string]string) (csvImport, error) {
+	var result csvImport
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return result, errors.New("it is empty")
+	} else if err != nil {
+		return result, err
+	}
+	// Spreadsheets often save CSV files with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	fields, ignored, err := mapColumns(header, mapping)
+	if err != nil {
+		return result, err
+	}
+	result.ignored = ignored
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		// A row with the wrong number of fields is still returned, and can be
+		// reported on its own, unlike broken quoting, which leaves no row to
+		// take a position from
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return result, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := ImportRow{Row: line}
+		if err != nil {
+			row.Status, row.Message = bulkInvalid, fmt.Sprintf("The row has %d fields, the header has %d", len(record), len(header))
+			result.rows = append(result.rows, row)
+			continue
+		}
+
+		m, err := recordMovie(record, fields)
+		if err == nil {
+			err = m.validate()
+		}
+		row.MovieID = m.MovieID
+
+		if err != nil {
+			row.Status, row.Message = bulkInvalid, err.Error()
+		} else {
+			result.movies = append(result.movies, m)
+			result.indexes = append(result.indexes, len(result.rows))
+		}
+		result.rows = append(result.rows, row)
+	}
+
+	if len(result.rows) == 0 {
+		return result, errors.New("it has no rows below its header")
+	}
+
+	return result, nil
+}
+
+// recordMovie fills a movie from the fields of a CSV record. Empty values
+// are left out, like omitted fields of a JSON movie. Every field is filled
+// even when one is invalid, so that the movieid of a bad row is reported.
+func recordMovie(record []string, fields []string) (Movie, error) {
+	var m Movie
+	var invalid error
+	for i, field := range fields {
+		value := strings.TrimSpace(record[i])
+		if field == "" || value == "" {
+			continue
+		}
+
+		var err error
+		switch field {
+		case "movieid":
+			m.MovieID = value
+		case "moviename":
+			m.MovieName = value
+		case "release_year":
+			m.ReleaseYear, err = strconv.Atoi(value)
+		case "runtime_minutes":
+			m.RuntimeMinutes, err = strconv.Atoi(value)
+		case "synopsis":
+			m.Synopsis = value
+		case "original_language":
+			m.OriginalLanguage = value
+		case "rating":
+			m.Rating = value
+		}
+
+		if err != nil && invalid == nil {
+			invalid = fmt.Errorf("%s must be a whole number", field)
+		}
+	}
+
+	return m, invalid
+}
+
+// importMovies creates the valid movies of a CSV import and fills in the
+// status of every row. An atomic import with invalid rows creates nothing,
+// but is still checked for duplicates as a dry run. Nothing is created when
+// the movies cannot be recorded in the audit log, see audited.
+func (s *server) importMovies(ctx context.Context, actor string, imported csvImport, opts importOptions) error {
+	invalid := len(imported.movies) < len(imported.rows)
+	dryRun := opts.dryRun || (opts.atomic && invalid)
+
+	var results []CreateResult
+	if len(imported.movies) > 0 {
+		err := s.auditedAs(ctx, actor, func(store Store) ([]AuditEntry, error) {
+			var err error
+			results, err = store.CreateMany(ctx, imported.movies, BatchOptions{Atomic: opts.atomic, DryRun: dryRun})
+
+			var entries []AuditEntry
+			for _, result := range results {
+				if result.Err == nil && !dryRun {
+					entries = append(entries, auditEntry("create", result.Movie.MovieID, nil, result.Movie))
+				}
+			}
+			return entries, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	heldBack := opts.atomic && invalid
+	for j, result := range results {
+		row := &imported.rows[imported.indexes[j]]
+		switch {
+		case result.Err == nil && opts.dryRun:
+			row.Status = importWouldCreate
+		case result.Err == nil && heldBack:
+			row.Status = bulkSkipped
+		case result.Err == nil:
+			row.Status = bulkCreated
+			s.titles.add(result.Movie.title())
+		case errors.Is(result.Err, ErrDuplicate):
+			row.Status, row.Message = bulkDuplicate, "A movie with that movieid already exists."
+		default:
+			row.Status = bulkSkipped
+		}
+	}
+
+	return nil
+}
+
+// importSummary counts the rows of an import by status, e.g. "3 created, 1 invalid"
+func importSummary(rows []ImportRow) string {
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[row.Status]++
+	}
+
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		parts[i] = fmt.Sprintf("%d %s", counts[status], status)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// importCount counts the rows of an import with the given status
+func importCount(rows []ImportRow, status string) int {
+	n := 0
+	for _, row := range rows {
+		if row.Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+// importCSV godoc
+// @Description Create movies from a CSV file whose first row is a header, sent as the request body or as the file field of a multipart form.
+// @Description Columns named after a movie field are imported into it, ignoring case, spaces and dashes, as are the columns title, name, year, runtime and language. map names the field of other columns, e.g. Title:moviename,Year:release_year. Other columns are ignored.
+// @Description Every row is validated like a single created movie. In the atomic mode, the default, one invalid or duplicate row means none are created. In the best_effort mode every valid row that is not a duplicate is created.
+// @Description data holds the status of every row: created, duplicate, invalid, skipped when a failed atomic import held it back, or would_create in a dry run, which writes nothing.
+// @Accept text/csv
+// @Produce json
+// @Param file body string true "The CSV file, at most 10 MB"
+// @Param map query string false "Column:field pairs separated by commas"
+// @Param mode query string false "atomic or best_effort" Enums(atomic, best_effort)
+// @Param dry_run query bool false "Only report what would be created"
+// @Success 201 {object} ImportResponse{type=string,data=[]ImportRow,message=string} "Every row has been created"
+// @Success 200 {object} ImportResponse{type=string,data=[]ImportRow,message=string} "A dry run, or some rows could not be created in the best_effort mode"
+// @Failure 400 {object} ImportResponse{type=string,data=[]ImportRow,message=string} "The file cannot be read or mapped, or an atomic import holds an invalid row"
+// @Failure 409 {object} ImportResponse{type=string,data=[]ImportRow,message=string} "An atomic import holds a duplicate row"
+// @Failure 500 {object} ImportResponse{type=string,message=string} "Fail to insert the movies"
+// @Router /movies/import/ [post]
+func (s *server) importCSV(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: POST /movies/import")
+
+	query := reader.URL.Query()
+	var opts importOptions
+	var err error
+	opts.atomic, err = parseBatchMode(query.Get("mode"))
+	if value := query.Get("dry_run"); value != "" && err == nil {
+		if opts.dryRun, err = strconv.ParseBool(value); err != nil {
+			err = errors.New("dry_run must be true or false")
+		}
+	}
+	if err == nil {
+		opts.mapping, err = parseImportMapping(query.Get("map"))
+	}
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(ImportResponse{Type: "error", Message: err.Error()})
+		return
+	}
+
+	reader.Body = http.MaxBytesReader(writer, reader.Body, maxImportSize)
+	var body io.Reader = reader.Body
+	if strings.HasPrefix(reader.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := reader.FormFile("file")
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(ImportResponse{Type: "error", Message: "The form must hold the CSV file in its file field"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	imported, err := readImport(body, opts.mapping)
+	if err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(ImportResponse{Type: "error", Message: "The CSV file cannot be imported: " + err.Error()})
+		return
+	}
+
+	if err := s.importMovies(reader.Context(), requestActor(reader), imported, opts); errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(ImportResponse{Type: "error", Message: notAuditedMessage})
+		return
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(ImportResponse{Type: "error", Message: "Failed to insert the movies"})
+		return
+	}
+
+	rows := imported.rows
+	var response = ImportResponse{Type: "success", Data: rows, IgnoredColumns: imported.ignored}
+	created := importCount(rows, bulkCreated)
+	switch {
+	case opts.dryRun:
+		response.Message = fmt.Sprintf("Dry run, nothing has been created: %s.", importSummary(rows))
+	case opts.atomic && importCount(rows, bulkInvalid) > 0:
+		writer.WriteHeader(http.StatusBadRequest)
+		response.Type, response.Message = "failure", "No movie has been created, as some rows are invalid."
+	case opts.atomic && created == 0:
+		writer.WriteHeader(http.StatusConflict)
+		response.Type, response.Message = "failure", "No movie has been created, as some already exist."
+	default:
+		if created == len(rows) {
+			writer.WriteHeader(http.StatusCreated)
+		}
+		response.Message = fmt.Sprintf("%d of %d rows have been created: %s.", created, len(rows), importSummary(rows))
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// runImport is the import subcommand, which imports a CSV file like the
+// import endpoint. The file is read from standard input when it is "-".
+func runImport(ctx context.Context, store Store, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only report what would be created")
+	bestEffort := flags.Bool("best-effort", false, "create every valid row that is not a duplicate, rather than nothing unless every row can be created")
+	mapping := flags.String("map", "", "column:field pairs separated by commas, e.g. Title:moviename,Year:release_year")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: import [-dry-run] [-best-effort] [-map column:field,...] file.csv")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: import [flags] file.csv")
+	}
+
+	opts := importOptions{atomic: !*bestEffort, dryRun: *dryRun}
+	var err error
+	if opts.mapping, err = parseImportMapping(*mapping); err != nil {
+		return err
+	}
+
+	var file io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		file = f
+	}
+
+	imported, err := readImport(file, opts.mapping)
+	if err != nil {
+		return err
+	}
+
+	if err := newServer(store).importMovies(ctx, cliActor, imported, opts); err != nil {
+		return err
+	}
+
+	if len(imported.ignored) > 0 {
+		fmt.Printf("Ignored columns: %s\n", strings.Join(imported.ignored, ", "))
+	}
+
+	// A dry run lists every row, an import only those that were not created
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, row := range imported.rows {
+		if row.Status != bulkCreated {
+			fmt.Fprintf(table, "line %d\t%s\t%s\t%s\n", row.Row, row.MovieID, row.Status, row.Message)
+		}
+	}
+	table.Flush()
+
+	created := importCount(imported.rows, bulkCreated)
+	if opts.dryRun {
+		fmt.Printf("Dry run, nothing has been created: %s\n", importSummary(imported.rows))
+	} else {
+		fmt.Printf("%d of %d rows have been created: %s\n", created, len(imported.rows), importSummary(imported.rows))
+	}
+
+	// Like the 400 and 409 of the endpoint, a failed atomic import exits
+	// with an error, so that scripts can tell
+	if opts.atomic && !opts.dryRun && created == 0 {
+		if importCount(imported.rows, bulkInvalid) > 0 {
+			return errors.New("no movie has been created, as some rows are invalid")
+		}
+		return errors.New("no movie has been created, as some already exist")
+	}
+
+	return nil
+}
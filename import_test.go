@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		mapping map[string]string
+		// want is the movieid or status of every row, and wantMovies the
+		// movieids of the valid movies
+		want       []ImportRow
+		wantMovies string
+		wantErr    string
+	}{
+		{
+			name:       "header with a byte order mark",
+			file:       "\ufeffmovieid,Title,Year\nm1,Alien,1979\n",
+			want:       []ImportRow{{Row: 2, MovieID: "m1"}},
+			wantMovies: "m1",
+		},
+		{
+			name: "row with the wrong number of fields",
+			file: "Title,Year,movieid\nFoo,2001,f1\nBar,2002\nBaz,2003,f3\n",
+			want: []ImportRow{
+				{Row: 2, MovieID: "f1"},
+				{Row: 3, Status: bulkInvalid, Message: "The row has 2 fields, the header has 3"},
+				{Row: 4, MovieID: "f3"},
+			},
+			wantMovies: "f1,f3",
+		},
+		{
+			name:    "unclosed quote",
+			file:    "Title,Year,movieid,extra\nFoo,2001,f1,x\n\"Baz,2002,f3\n",
+			wantErr: "extraneous or missing \" in quoted-field",
+		},
+		{
+			name:    "bare quote",
+			file:    "Title,movieid\nFo\"o,f1\n",
+			wantErr: "bare \" in non-quoted-field",
+		},
+		{
+			name: "quoted field over several lines",
+			file: "movieid,moviename,synopsis\nm1,Alien,\"In space,\nno one\"\nm2,Heat,\n",
+			want: []ImportRow{
+				{Row: 2, MovieID: "m1"},
+				{Row: 4, MovieID: "m2"},
+			},
+			wantMovies: "m1,m2",
+		},
+		{
+			name:       "mapped and ignored columns",
+			file:       "Code,Name,Notes\nm1,Alien,classic\n",
+			mapping:    map[string]string{"Code": "movieid"},
+			want:       []ImportRow{{Row: 2, MovieID: "m1"}},
+			wantMovies: "m1",
+		},
+		{
+			name: "bad number before the movieid",
+			file: "Title,Year,movieid\nBar,abc,f2\n",
+			want: []ImportRow{{Row: 2, MovieID: "f2", Status: bulkInvalid, Message: "release_year must be a whole number"}},
+		},
+		{
+			name: "invalid movie",
+			file: "movieid,moviename,rating\nm1,Alien,X\n",
+			want: []ImportRow{{Row: 2, MovieID: "m1", Status: bulkInvalid, Message: "rating must be one of G, PG, PG-13, R, NC-17, NR"}},
+		},
+		{name: "empty file", file: "", wantErr: "it is empty"},
+		{name: "header alone", file: "movieid,moviename\n", wantErr: "it has no rows below its header"},
+		{name: "no movieid column", file: "moviename\nAlien\n", wantErr: "no column maps to movieid, which every movie needs"},
+		{name: "two columns for a field", file: "movieid,Title,Name\nm1,Alien,Alien\n", wantErr: `columns "Title" and "Name" both map to moviename`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			imported, err := readImport(strings.NewReader(test.file), test.mapping)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+
+			if len(imported.rows) != len(test.want) {
+				t.Fatalf("got rows %+v, want %+v", imported.rows, test.want)
+			}
+			for i, row := range imported.rows {
+				if row != test.want[i] {
+					t.Errorf("got row %+v, want %+v", row, test.want[i])
+				}
+			}
+
+			if got := movieIDs(imported.movies); got != test.wantMovies {
+				t.Errorf("got movies %s, want %s", got, test.wantMovies)
+			}
+		})
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+
+	importCSV := func(query string, file string, status int) ImportResponse {
+		t.Helper()
+		recorder := serve(t, router, "POST", "/movies/import/"+query, file, "Content-Type", "text/csv")
+		if recorder.Code != status {
+			t.Fatalf("got status %d, want %d: %s", recorder.Code, status, recorder.Body.String())
+		}
+		var response ImportResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	// Broken quoting refuses the whole file rather than crashing
+	importCSV("?dry_run=true", "Title,Year,movieid,extra\nFoo,2001,f1,x\n\"Baz,2002,f3\n", http.StatusBadRequest)
+
+	file := "movieid,Title,Year\nm1,Aliens,1986\nm2,Heat,1995\nm3,Up\n"
+	response := importCSV("?dry_run=true&mode=best_effort", file, http.StatusOK)
+	if got := importSummary(response.Data); got != "1 duplicate, 1 invalid, 1 would_create" {
+		t.Fatalf("got %s on a dry run", got)
+	}
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m2/", ""), http.StatusNotFound)
+
+	importCSV("", file, http.StatusBadRequest)
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m2/", ""), http.StatusNotFound)
+
+	response = importCSV("?mode=best_effort", file, http.StatusOK)
+	if got := importSummary(response.Data); got != "1 created, 1 duplicate, 1 invalid" {
+		t.Fatalf("got %s", got)
+	}
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m2/", ""), http.StatusOK)
+}
+
+func TestRunImportFailsWhenAnAtomicImportCreatesNothing(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "movies.csv")
+	if err := os.WriteFile(name, []byte("movieid,moviename,year\nm1,Alien,1979\nm2,Heat,soon\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The summary goes to standard output
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = stdout }()
+
+	store := newMemoryStore()
+	if err := runImport(context.Background(), store, []string{name}); err == nil {
+		t.Fatal("an atomic import with an invalid row succeeded")
+	}
+	if err := runImport(context.Background(), store, []string{"-dry-run", name}); err != nil {
+		t.Fatalf("a dry run failed: %v", err)
+	}
+	if _, err := store.Get(context.Background(), "m1"); err == nil {
+		t.Fatal("the atomic import created m1")
+	}
+
+	if err := runImport(context.Background(), store, []string{"-best-effort", name}); err != nil {
+		t.Fatalf("a best effort import failed: %v", err)
+	}
+	if err := runImport(context.Background(), store, []string{name}); err == nil {
+		t.Fatal("an atomic import of duplicates succeeded")
+	}
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
+	"mime"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/ArKane-6418/mux-movies-api/docs"
 	"github.com/gorilla/mux"
@@ -32,33 +41,104 @@ import (
 // @schemes http
 
 type Movie struct {
+	ID        int    `json:"-"`
 	MovieID   string `json:"movieid"`
 	MovieName string `json:"moviename"`
+	// Year of the first theatrical release
+	ReleaseYear int `json:"release_year,omitempty"`
+	// Running time in minutes
+	RuntimeMinutes int    `json:"runtime_minutes,omitempty"`
+	Synopsis       string `json:"synopsis,omitempty"`
+	// Two letter ISO 639-1 code of the original language, e.g. en
+	OriginalLanguage string `json:"original_language,omitempty"`
+	// Content rating: G, PG, PG-13, R, NC-17 or NR
+	Rating string `json:"rating,omitempty"`
+	// Genres are attached and detached through /movies/{movieid}/genres/{genreid}/
+	Genres []Genre `json:"genres"`
+	// Incremented on every change. The ETag of the movie is made from it.
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Only set on movies in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type JsonResponse struct {
 	Type    string  `json:"type"`
 	Data    []Movie `json:"data"`
 	Message string  `json:"message"`
+	// Meta is only set when listing movies
+	Meta *ListMeta `json:"meta,omitempty"`
+	// Errors lists the query parameters that were refused
+	Errors []ParamError `json:"errors,omitempty"`
+	// Suggestions are the closest movies when a movieid is not found
+	Suggestions []MovieTitle `json:"suggestions,omitempty"`
+
+	// fields are the movie fields to encode, or nil for all of them
+	fields []string
 }
 
-// DB set up
-func setupDB() *sql.DB {
+// server holds the dependencies shared by the HTTP handlers
+type server struct {
+	store  Store
+	titles *titleIndex
+}
+
+func newServer(store Store) *server {
+	return &server{store: store, titles: newTitleIndex(store.ListTitles)}
+}
+
+// Load the .env file if there is one. It is optional so that the server can
+// run with the memory store and no other configuration.
+func loadEnv() {
 	err := godotenv.Load(".env")
 
-	if err != nil {
-		log.Fatalf("Error loading .env file")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
 	}
+}
 
-	fmt.Println("Setting up DB")
-	dbinfo := fmt.Sprintf("user=%s password=%s dbname=%s port=%s sslmode=disable", os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), os.Getenv("PORT"))
-	db, err := sql.Open("postgres", dbinfo)
+// Look up an environment variable, falling back to def when it is unset
+func getEnv(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
+
+// DB set up. Progress goes to standard error, which keeps the standard
+// output of subcommands like export clean.
+func setupDB(cfg storeConfig) *sql.DB {
+	fmt.Fprintf(os.Stderr, "Setting up %s DB\n", cfg.kind)
+	db, err := sql.Open(cfg.dialect.driver, cfg.dsn)
 
 	checkErr(err)
 
 	return db
 }
 
+// Store set up, SQL stores must have an up to date schema
+func setupStore(cfg storeConfig) (Store, error) {
+	if cfg.kind == "memory" {
+		fmt.Fprintln(os.Stderr, "Using the in-memory store, movies will be lost on shutdown")
+		return newMemoryStore(), nil
+	}
+
+	db := setupDB(cfg)
+
+	mg, err := newMigrator(db, cfg.dialect.name)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := checkSchema(context.Background(), mg); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return newSQLStore(db, cfg.dialect), nil
+}
+
 // Function for handling errors
 func checkErr(err error) {
 	if err != nil {
@@ -72,43 +152,94 @@ func printMessage(message string) {
 	fmt.Println("")
 }
 
+// randomID returns a random hex string, used for snapshot and request IDs
+func randomID() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	checkErr(err)
+	return hex.EncodeToString(b)
+}
+
 // getMovies godoc
-// @Description Get all movies from the database
+// @Description Get all movies from the database, or a page of them when limit, offset or cursor is given.
+// @Description Pages can be followed through the Link header or the cursors in meta.
+// @Description Without paging the movies are streamed as they are read, and a failure part way through cuts the response off.
+// @Description Unknown query parameters are refused, with every refused parameter listed in errors. A movie without a year, runtime, language or rating matches no filter on that field.
 // @Produce json
-// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully get all movies"
+// @Param limit query int false "Movies per page, 50 by default and 500 at most"
+// @Param offset query int false "Movies to skip"
+// @Param cursor query string false "next_cursor or prev_cursor of another page, instead of offset"
+// @Param sort query string false "Comma separated fields to sort by, descending when prefixed with -, e.g. moviename,-id. One of id, movieid, moviename, release_year, runtime_minutes, original_language, rating"
+// @Param moviename query string false "Only movies with exactly this name"
+// @Param name_prefix query string false "Only movies whose name starts with this, ignoring case"
+// @Param release_year query int false "Only movies released this year"
+// @Param release_year_min query int false "Only movies released this year or later"
+// @Param release_year_max query int false "Only movies released this year or earlier"
+// @Param runtime_minutes query int false "Only movies running this long"
+// @Param runtime_minutes_min query int false "Only movies running at least this long"
+// @Param runtime_minutes_max query int false "Only movies running at most this long"
+// @Param original_language query string false "Only movies in this language"
+// @Param rating query string false "Only movies with this rating"
+// @Param genre query string false "Only movies classified under the genre with this genreid"
+// @Param fields query string false "Comma separated fields to return, e.g. movieid,moviename. Every field by default"
+// @Param If-None-Match header string false "ETag of the list the client already has"
+// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string,meta=ListMeta} "Successfully get all movies"
+// @Success 304 "No movie has changed"
+// @Header 200 {string} ETag "Version of the list"
+// @Header 200 {string} Link "The next and prev pages"
+// @Failure 400 {object} JsonResponse{type=string,message=string,errors=[]ParamError} "A query parameter is unknown or invalid"
 // @Failure 500 {object} JsonResponse{type=string,message=string} "Fail to get all movies"
 // @Router /movies/ [get]
-func getMovies(writer http.ResponseWriter, reader *http.Request) {
+func (s *server) getMovies(writer http.ResponseWriter, reader *http.Request) {
 	log.Println("Endpoint hit: /movies")
-	db := setupDB()
 
 	printMessage("Getting movies...")
 
-	rows, err := db.Query("SELECT * FROM movies")
+	page, errs := parseMovieList(reader.URL.Query())
+	if len(errs) > 0 {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "Invalid query parameters", Errors: errs})
+		return
+	}
 
-	if err != nil {
-		writer.WriteHeader(http.StatusInternalServerError)
-		var response = JsonResponse{Type: "error", Message: "Failed to get all movies from the database"}
-		json.NewEncoder(writer).Encode(response)
+	// The fingerprint is taken before the movies are listed, so a change
+	// in between can only make the ETag older than the list, never newer
+	fingerprint, err := s.store.Fingerprint(reader.Context())
+	if err == nil && notModified(writer, reader, listETag(fingerprint, reader.URL.RawQuery), time.Time{}) {
 		return
 	}
 
-	var movies []Movie
+	// Without paging every movie is listed, so they are streamed rather
+	// than gathered. A page is small enough to gather, which its metadata
+	// and Link header need before it is written.
+	if err == nil && !page.paged {
+		s.streamMovies(writer, reader, page.query())
+		return
+	}
 
-	// Iterate through the query results
-	for rows.Next() {
-		var id int
-		var movieID string
-		var movieName string
+	var movies []Movie
+	if err == nil {
+		movies, err = s.store.List(reader.Context(), page.query())
+	}
 
-		err = rows.Scan(&id, &movieID, &movieName)
+	var total int
+	if err == nil {
+		total, err = s.store.Count(reader.Context(), page.query())
+	}
 
-		checkErr(err)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		var response = JsonResponse{Type: "error", Message: "Failed to get all movies from the database"}
+		json.NewEncoder(writer).Encode(response)
+		return
+	}
 
-		movies = append(movies, Movie{MovieID: movieID, MovieName: movieName})
+	movies, meta, links := page.paginate(movies, total, reader.URL)
+	if links != "" {
+		writer.Header().Set("Link", links)
 	}
 
-	var response = JsonResponse{Type: "success", Data: movies, Message: "Successfully got all movies from DB"}
+	var response = JsonResponse{Type: "success", Data: movies, Message: "Successfully got all movies from DB", Meta: &meta, fields: page.filter.Fields}
 	printMessage("Successfully got all movies from DB")
 	json.NewEncoder(writer).Encode(response)
 }
@@ -117,11 +248,17 @@ func getMovies(writer http.ResponseWriter, reader *http.Request) {
 // @Description Get a movie by its movieid
 // @Produce json
 // @Param movieid path string true "Movie ID"
+// @Param fields query string false "Comma separated fields to return, e.g. movieid,moviename. Every field by default"
 // @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully get a movie with the specified movieid"
-// @Failure 400 {object} JsonResponse{type=string,message=string} "movieid is not provided"
-// @Failure 404 {object} JsonResponse{type=string,message=string} "A movie with the specified movieid could not be found"
+// @Param If-None-Match header string false "ETag of the copy the client already has"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client already has"
+// @Success 304 "The movie has not changed"
+// @Header 200 {string} ETag "Row ID and version of the movie, to send back in If-Match or If-None-Match"
+// @Header 200 {string} Last-Modified "When the movie last changed"
+// @Failure 400 {object} JsonResponse{type=string,message=string,errors=[]ParamError} "movieid is not provided, or a field is unknown"
+// @Failure 404 {object} JsonResponse{type=string,message=string,suggestions=[]MovieTitle} "A movie with the specified movieid could not be found, with the closest movies as suggestions"
 // @Router /getmovie/{movieid}/ [get]
-func getMovie(writer http.ResponseWriter, reader *http.Request) {
+func (s *server) getMovie(writer http.ResponseWriter, reader *http.Request) {
 	log.Println("Endpoint hit: /getmovie/{movieid}")
 	// Get the map of route variables from the reader
 	params := mux.Vars(reader)
@@ -129,34 +266,32 @@ func getMovie(writer http.ResponseWriter, reader *http.Request) {
 	movieID := params["movieid"]
 
 	var response = JsonResponse{}
+	fields, fieldsErr := parseFields(reader.URL.Query().Get("fields"))
 
 	// movieID must be provided
 	if movieID == " " {
 		writer.WriteHeader(http.StatusBadRequest)
 		response = JsonResponse{Type: "error", Message: "You are missing the movieid parameter."}
+	} else if fieldsErr != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		response = JsonResponse{Type: "error", Message: "Invalid query parameters", Errors: []ParamError{{Param: "fields", Message: fieldsErr.Error()}}}
 	} else {
-		db := setupDB()
 		printMessage("Getting movie from DB")
 
-		// Execute the query but don't return any rows
-		row := db.QueryRow("SELECT * FROM movies WHERE movieid = $1", movieID)
+		m, err := s.getMovieFields(reader.Context(), movieID, fields)
 
-		var movies []Movie
-
-		var id int
-		var movieID string
-		var movieName string
-
-		err := row.Scan(&id, &movieID, &movieName)
-
-		if err != nil {
+		if errors.Is(err, ErrNotFound) {
 			writer.WriteHeader(http.StatusNotFound)
-			response = JsonResponse{Type: "failure", Message: "A movie with that movieid does not exist."}
+			response = JsonResponse{Type: "failure", Message: "A movie with that movieid does not exist.", Suggestions: s.suggest(reader.Context(), movieID)}
+		} else if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			response = JsonResponse{Type: "error", Message: "Failed to get the movie from the database"}
 		} else {
-			movies = append(movies, Movie{MovieID: movieID, MovieName: movieName})
-
+			if notModified(writer, reader, movieETag(m), m.UpdatedAt) {
+				return
+			}
 			printMessage("Successfully got movie from DB")
-			response = JsonResponse{Type: "success", Data: movies, Message: "Successfully got movie from DB"}
+			response = JsonResponse{Type: "success", Data: []Movie{m}, Message: "Successfully got movie from DB", fields: fields}
 		}
 	}
 
@@ -169,35 +304,48 @@ func getMovie(writer http.ResponseWriter, reader *http.Request) {
 // @Produce json
 // @Param movie body Movie true "Movie Data"
 // @Success 201 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully create a new movie with the specified movieid and moviename"
-// @Failure 400 {object} JsonResponse{type=string,message=string} "Fail to create a new movie because at least one of the parameters is missing"
+// @Failure 400 {object} JsonResponse{type=string,message=string} "Fail to create a new movie because a parameter is missing or invalid"
+// @Failure 409 {object} JsonResponse{type=string,message=string} "A movie with the specified movieid already exists"
+// @Failure 500 {object} JsonResponse{type=string,message=string} "Fail to insert the new movie"
 // @Router /addmovie/ [post]
-func createMovie(writer http.ResponseWriter, reader *http.Request) {
+func (s *server) createMovie(writer http.ResponseWriter, reader *http.Request) {
 	log.Println("Endpoint hit: /addmovie")
 
 	var m Movie
 	decoder := json.NewDecoder(reader.Body)
 	if err := decoder.Decode(&m); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "The request body is not a valid movie"})
 		return
 	}
 
 	var response = JsonResponse{}
-	// movieID and movieName must both be provided
-	if m.MovieID == "" || m.MovieName == "" {
+	// movieID and movieName must both be provided, and the rest must be valid
+	if err := m.validate(); err != nil {
 		writer.WriteHeader(http.StatusBadRequest)
-		response = JsonResponse{Type: "error", Message: "You are missing movieID or movieName"}
+		response = JsonResponse{Type: "error", Message: err.Error()}
 	} else {
-		// Setup the DB and insert a new record
-		db := setupDB()
+		// Insert a new record
 		printMessage("Inserting movie into DB")
 		fmt.Printf("Inserting new movie with ID %s and name %s\n", m.MovieID, m.MovieName)
-		var lastInsertID int
-		// Execute the query and get the first (and only) row
-		err := db.QueryRow("INSERT INTO movies(movieid, moviename) VALUES($1, $2) RETURNING id", m.MovieID, m.MovieName).Scan(&lastInsertID)
-
-		if err != nil {
+		var created Movie
+		err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+			var err error
+			created, err = store.Create(reader.Context(), m)
+			return []AuditEntry{auditEntry("create", m.MovieID, nil, created)}, err
+		})
+
+		if errors.Is(err, ErrDuplicate) {
+			writer.WriteHeader(http.StatusConflict)
+			response = JsonResponse{Type: "error", Message: "A movie with that movieid already exists."}
+		} else if errors.Is(err, errNotAudited) {
+			writer.WriteHeader(http.StatusInternalServerError)
+			response = JsonResponse{Type: "error", Message: notAuditedMessage}
+		} else if err != nil {
 			writer.WriteHeader(http.StatusInternalServerError)
 			response = JsonResponse{Type: "error", Message: "Failed to insert a new movie"}
 		} else {
+			s.titles.add(created.title())
 			writer.WriteHeader(http.StatusCreated)
 			response = JsonResponse{Type: "success", Message: "The movie has been inserted successfully!"}
 		}
@@ -206,13 +354,188 @@ func createMovie(writer http.ResponseWriter, reader *http.Request) {
 	json.NewEncoder(writer).Encode(response)
 }
 
+// updateMovie godoc
+// @Description Replace every field of a movie. Fields that are left out are cleared, genres are kept.
+// @Accept json
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Param movie body Movie true "Movie Data"
+// @Param If-Match header string false "ETag of the version being replaced"
+// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully replace the movie with the specified movieid"
+// @Header 200 {string} ETag "Row ID and new version of the movie"
+// @Failure 400 {object} JsonResponse{type=string,message=string} "A parameter is missing or invalid"
+// @Failure 404 {object} JsonResponse{type=string,message=string} "A movie with the specified movieid could not be found"
+// @Failure 409 {object} JsonResponse{type=string,message=string} "The movie was changed by another request while it was being replaced"
+// @Failure 412 {object} JsonResponse{type=string,message=string} "The movie has changed since the version in If-Match"
+// @Failure 500 {object} JsonResponse{type=string,message=string} "Fail to update the movie"
+// @Router /updatemovie/{movieid}/ [put]
+func (s *server) updateMovie(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: PUT /updatemovie/{movieid}")
+	// Get the map of route variables from the reader
+	params := mux.Vars(reader)
+
+	movieID := params["movieid"]
+
+	var m Movie
+	decoder := json.NewDecoder(reader.Body)
+	if err := decoder.Decode(&m); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "The request body is not a valid movie"})
+		return
+	}
+
+	// The movieid may be left out of the body, but it cannot be changed
+	if m.MovieID == "" {
+		m.MovieID = movieID
+	}
+
+	var response = JsonResponse{}
+
+	if m.MovieID != movieID {
+		writer.WriteHeader(http.StatusBadRequest)
+		response = JsonResponse{Type: "error", Message: "The movieid in the body does not match the URL"}
+	} else if err := m.validate(); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		response = JsonResponse{Type: "error", Message: err.Error()}
+	} else {
+		printMessage("Updating movie in DB")
+
+		ifMatch := reader.Header.Get("If-Match")
+		before, err := s.matchingMovie(reader, movieID)
+		if err == nil {
+			err = s.audited(reader, func(store Store) ([]AuditEntry, error) {
+				var err error
+				m, err = store.Update(reader.Context(), movieID, m, before.Version)
+				return []AuditEntry{auditEntry("update", movieID, before, m)}, err
+			})
+		}
+
+		if errors.Is(err, ErrNotFound) {
+			writer.WriteHeader(http.StatusNotFound)
+			response = JsonResponse{Type: "failure", Message: "A movie with that movieid does not exist."}
+		} else if errors.Is(err, ErrVersionConflict) && ifMatch != "" {
+			writer.WriteHeader(http.StatusPreconditionFailed)
+			response = JsonResponse{Type: "failure", Message: "The movie has been changed since it was fetched, get it again and retry."}
+		} else if errors.Is(err, ErrVersionConflict) {
+			writer.WriteHeader(http.StatusConflict)
+			response = JsonResponse{Type: "failure", Message: "The movie was changed by another request while it was being updated, retry."}
+		} else if errors.Is(err, errNotAudited) {
+			writer.WriteHeader(http.StatusInternalServerError)
+			response = JsonResponse{Type: "error", Message: notAuditedMessage}
+		} else if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			response = JsonResponse{Type: "error", Message: "Failed to update the movie"}
+		} else {
+			s.titles.add(m.title())
+			writer.Header().Set("ETag", movieETag(m))
+			response = JsonResponse{Type: "success", Data: []Movie{m}, Message: "The movie has been updated successfully."}
+		}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// patchMovie godoc
+// @Description Change some fields of a movie with a JSON Merge Patch (RFC 7396). Fields set to null are cleared.
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Param patch body Movie true "Fields to change"
+// @Param If-Match header string false "ETag of the version being patched"
+// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully patch the movie with the specified movieid"
+// @Header 200 {string} ETag "Row ID and new version of the movie"
+// @Failure 400 {object} JsonResponse{type=string,message=string} "The patch is malformed or leaves the movie invalid"
+// @Failure 404 {object} JsonResponse{type=string,message=string} "A movie with the specified movieid could not be found"
+// @Failure 409 {object} JsonResponse{type=string,message=string} "The movie was changed by another request while it was being patched"
+// @Failure 412 {object} JsonResponse{type=string,message=string} "The movie has changed since the version in If-Match"
+// @Failure 415 {object} JsonResponse{type=string,message=string} "The patch is not sent as application/merge-patch+json"
+// @Failure 500 {object} JsonResponse{type=string,message=string} "Fail to update the movie"
+// @Router /updatemovie/{movieid}/ [patch]
+func (s *server) patchMovie(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: PATCH /updatemovie/{movieid}")
+	// Get the map of route variables from the reader
+	params := mux.Vars(reader)
+
+	movieID := params["movieid"]
+
+	// Plain JSON is accepted too, since a merge patch is just a JSON object
+	contentType, _, _ := mime.ParseMediaType(reader.Header.Get("Content-Type"))
+	if contentType != "application/merge-patch+json" && contentType != "application/json" {
+		writer.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "Send the patch as application/merge-patch+json"})
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(reader.Body).Decode(&patch); err != nil || patch == nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "The patch must be a JSON object"})
+		return
+	}
+
+	var response = JsonResponse{}
+
+	ifMatch := reader.Header.Get("If-Match")
+	before, err := s.matchingMovie(reader, movieID)
+	var m Movie
+
+	if errors.Is(err, ErrNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = JsonResponse{Type: "failure", Message: "A movie with that movieid does not exist."}
+	} else if errors.Is(err, ErrVersionConflict) {
+		writer.WriteHeader(http.StatusPreconditionFailed)
+		response = JsonResponse{Type: "failure", Message: "The movie has been changed since it was fetched, get it again and retry."}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: "Failed to get the movie from the database"}
+	} else if m, err = applyMoviePatch(before, patch); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		response = JsonResponse{Type: "error", Message: err.Error()}
+	} else {
+		printMessage("Patching movie in DB")
+		// The patch was applied to this version, so it must still be current
+		err = s.audited(reader, func(store Store) ([]AuditEntry, error) {
+			var err error
+			m, err = store.Update(reader.Context(), movieID, m, before.Version)
+			return []AuditEntry{auditEntry("update", movieID, before, m)}, err
+		})
+
+		if errors.Is(err, ErrNotFound) {
+			writer.WriteHeader(http.StatusNotFound)
+			response = JsonResponse{Type: "failure", Message: "A movie with that movieid does not exist."}
+		} else if errors.Is(err, ErrVersionConflict) && ifMatch != "" {
+			writer.WriteHeader(http.StatusPreconditionFailed)
+			response = JsonResponse{Type: "failure", Message: "The movie has been changed since it was fetched, get it again and retry."}
+		} else if errors.Is(err, ErrVersionConflict) {
+			writer.WriteHeader(http.StatusConflict)
+			response = JsonResponse{Type: "failure", Message: "The movie was changed by another request while it was being patched, retry."}
+		} else if errors.Is(err, errNotAudited) {
+			writer.WriteHeader(http.StatusInternalServerError)
+			response = JsonResponse{Type: "error", Message: notAuditedMessage}
+		} else if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			response = JsonResponse{Type: "error", Message: "Failed to update the movie"}
+		} else {
+			s.titles.add(m.title())
+			writer.Header().Set("ETag", movieETag(m))
+			response = JsonResponse{Type: "success", Data: []Movie{m}, Message: "The movie has been updated successfully."}
+		}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
 // deleteMovie godoc
-// @Description Delete movie based on movieid
+// @Description Move a movie to the trash based on movieid. It can be restored or purged through /trash/.
 // @Produce json
 // @Param movieid path string true "Movie ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} JsonResponse{type=string,message=string} "Successfully delete a movie with the specified movieid"
+// @Failure 409 {object} JsonResponse{type=string,message=string} "The movie was changed by another request while it was being deleted"
+// @Failure 412 {object} JsonResponse{type=string,message=string} "The movie has changed since the version in If-Match, or no longer exists"
 // @Router /deletemovie/{movieid}/ [delete]
-func deleteMovie(writer http.ResponseWriter, reader *http.Request) {
+func (s *server) deleteMovie(writer http.ResponseWriter, reader *http.Request) {
 	log.Println("Endpoint hit: /deletemovie/{movieid}")
 	// Get the map of route variables from the reader
 	params := mux.Vars(reader)
@@ -221,73 +544,291 @@ func deleteMovie(writer http.ResponseWriter, reader *http.Request) {
 
 	var response = JsonResponse{}
 
-	db := setupDB()
 	printMessage("Deleting movie from DB")
 
-	// Execute the query but don't return any rows
-	_, err := db.Exec("DELETE FROM movies WHERE movieid = $1", movieID)
+	ifMatch := reader.Header.Get("If-Match")
+	before, err := s.matchingMovie(reader, movieID)
+	if err == nil {
+		err = s.audited(reader, func(store Store) ([]AuditEntry, error) {
+			err := store.Delete(reader.Context(), movieID, before.Version)
+			return []AuditEntry{auditEntry("delete", movieID, before, nil)}, err
+		})
+	}
 
-	if err != nil {
+	// Deleting a movie that does not exist is not treated as a failure,
+	// unless the request expected a specific version of it
+	if ifMatch != "" && (errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrNotFound)) {
+		writer.WriteHeader(http.StatusPreconditionFailed)
+		response = JsonResponse{Type: "failure", Message: "The movie has been changed since it was fetched, get it again and retry."}
+	} else if errors.Is(err, ErrVersionConflict) {
+		writer.WriteHeader(http.StatusConflict)
+		response = JsonResponse{Type: "failure", Message: "The movie was changed by another request while it was being deleted, retry."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil && !errors.Is(err, ErrNotFound) {
 		response = JsonResponse{Type: "failure", Message: "Failed to delete the specified movie."}
 	} else {
-		response = JsonResponse{Type: "success", Message: "The movie has been deleted successfully."}
+		response = JsonResponse{Type: "success", Message: "The movie has been moved to the trash."}
+		if err == nil {
+			s.titles.remove(movieID)
+		}
 	}
 
 	json.NewEncoder(writer).Encode(response)
 }
 
+// Deleting all movies must be confirmed by sending this header and value
+const (
+	confirmDeleteHeader = "X-Confirm-Delete"
+	confirmDeleteAll    = "all-movies"
+)
+
 // deleteAllMovies godoc
-// @Description Delete all movies from database
+// @Description Permanently delete all movies from database, including the trash. The request must carry the header "X-Confirm-Delete: all-movies".
+// @Description The movies are saved in a snapshot first, whose snapshotid can be used to undo the wipe through /snapshots/{snapshotid}/restore/.
 // @Produce json
-// @Success 200 {object} JsonResponse{type=string,message=string} "Succesfully delete all movies"
-// @Failure 500 {object} JsonResponse{type=string,message=string} "Fail to delete all movies"
+// @Param X-Confirm-Delete header string false "Must be all-movies, unless dry_run is set"
+// @Param dry_run query bool false "Only count the movies that would be deleted"
+// @Success 200 {object} SnapshotResponse{type=string,data=[]Snapshot,message=string} "Succesfully delete all movies, or count them on a dry run"
+// @Failure 400 {object} SnapshotResponse{type=string,message=string} "dry_run is not a boolean"
+// @Failure 428 {object} SnapshotResponse{type=string,message=string} "The confirmation header is missing"
+// @Failure 500 {object} SnapshotResponse{type=string,message=string} "Fail to delete all movies"
 // @Router /deletemovies/ [delete]
-func deleteAllMovies(writer http.ResponseWriter, reader *http.Request) {
+func (s *server) deleteAllMovies(writer http.ResponseWriter, reader *http.Request) {
 	log.Println("Endpoint hit: /deletemovies")
-	db := setupDB()
+
+	dryRun := false
+	if value := reader.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(SnapshotResponse{Type: "error", Message: "dry_run must be true or false"})
+			return
+		}
+	}
+
+	if dryRun {
+		count, err := s.store.CountAll(reader.Context())
+		if err != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(writer).Encode(SnapshotResponse{Type: "error", Message: "Failed to count the movies in the database"})
+			return
+		}
+
+		// The snapshot that would be taken, without a snapshotid
+		var response = SnapshotResponse{
+			Type:    "success",
+			Data:    []Snapshot{{MovieCount: count, CreatedAt: now()}},
+			Message: fmt.Sprintf("Dry run: %d movies would be deleted.", count),
+		}
+		json.NewEncoder(writer).Encode(response)
+		return
+	}
+
+	if reader.Header.Get(confirmDeleteHeader) != confirmDeleteAll {
+		writer.WriteHeader(http.StatusPreconditionRequired)
+		var response = SnapshotResponse{
+			Type:    "failure",
+			Message: fmt.Sprintf("Deleting all movies must be confirmed with the header %q.", confirmDeleteHeader+": "+confirmDeleteAll),
+		}
+		json.NewEncoder(writer).Encode(response)
+		return
+	}
 
 	printMessage("Deleting all movies...")
 
-	_, err := db.Exec("DELETE FROM movies")
+	var snap Snapshot
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		var err error
+		snap, err = store.DeleteAll(reader.Context())
+		return []AuditEntry{auditEntry("delete_all", "", snap, nil)}, err
+	})
 
-	if err != nil {
+	if errors.Is(err, errNotAudited) {
 		writer.WriteHeader(http.StatusInternalServerError)
-		var response = JsonResponse{Type: "error", Message: "Failed to get all movies from the database"}
+		json.NewEncoder(writer).Encode(SnapshotResponse{Type: "error", Message: notAuditedMessage})
+		return
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		var response = SnapshotResponse{Type: "error", Message: "Failed to delete all movies from the database"}
 		json.NewEncoder(writer).Encode(response)
 		return
 	}
 
 	printMessage("All movies have been deleted successfully!")
+	s.titles.invalidate()
 
-	var response = JsonResponse{Type: "success", Message: "All movies have been deleted successfully!"}
+	var response = SnapshotResponse{
+		Type:    "success",
+		Data:    []Snapshot{snap},
+		Message: fmt.Sprintf("All movies have been deleted successfully! Undo with POST /snapshots/%s/restore/", snap.SnapshotID),
+	}
 
 	json.NewEncoder(writer).Encode(response)
 }
 
-func main() {
-
+// newRouter registers every route handled by the server
+func newRouter(s *server) *mux.Router {
 	// Initialize the mux router
 	router := mux.NewRouter().StrictSlash(true)
+	router.Use(withRequestID)
 
 	// Route handles & endpoints
 
 	// Get all movies
-	router.HandleFunc("/movies/", getMovies).Methods("GET")
+	router.HandleFunc("/movies/", s.getMovies).Methods("GET")
+
+	// Search movies by the words in their names and synopses
+	router.HandleFunc("/movies/search/", s.searchMovies).Methods("GET")
+
+	// Complete movie titles as they are typed
+	router.HandleFunc("/movies/autocomplete/", s.autocompleteMovies).Methods("GET")
 
 	// Get a specific movie by the movieID
-	router.HandleFunc("/getmovie/{movieid}/", getMovie).Methods("GET")
+	router.HandleFunc("/getmovie/{movieid}/", s.getMovie).Methods("GET")
 
 	// Create a movie
-	router.HandleFunc("/addmovie/", createMovie).Methods("POST")
+	router.HandleFunc("/addmovie/", s.createMovie).Methods("POST")
+
+	// Create many movies at once
+	router.HandleFunc("/movies/bulk/", s.createMovies).Methods("POST")
+
+	// Create movies from a CSV file
+	router.HandleFunc("/movies/import/", s.importCSV).Methods("POST")
+
+	// Export every movie as CSV or NDJSON
+	router.HandleFunc("/movies/export/", s.exportCatalogue).Methods("GET")
+
+	// Delete many movies at once
+	router.HandleFunc("/movies/bulk/", s.deleteMovies).Methods("DELETE")
+
+	// Replace a specific movie by the movieID
+	router.HandleFunc("/updatemovie/{movieid}/", s.updateMovie).Methods("PUT")
+
+	// Change some fields of a specific movie by the movieID
+	router.HandleFunc("/updatemovie/{movieid}/", s.patchMovie).Methods("PATCH")
 
 	// Delete a specific movie by the movieID
-	router.HandleFunc("/deletemovie/{movieid}/", deleteMovie).Methods("DELETE")
+	router.HandleFunc("/deletemovie/{movieid}/", s.deleteMovie).Methods("DELETE")
 
 	// Delete all movies
-	router.HandleFunc("/deletemovies/", deleteAllMovies).Methods("DELETE")
+	router.HandleFunc("/deletemovies/", s.deleteAllMovies).Methods("DELETE")
+
+	// Genres
+	router.HandleFunc("/genres/", s.listGenres).Methods("GET")
+	router.HandleFunc("/genres/", s.createGenre).Methods("POST")
+	router.HandleFunc("/genres/{genreid}/", s.getGenre).Methods("GET")
+	router.HandleFunc("/genres/{genreid}/", s.updateGenre).Methods("PUT")
+	router.HandleFunc("/genres/{genreid}/", s.deleteGenre).Methods("DELETE")
+
+	// Attach and detach genres on a movie
+	router.HandleFunc("/movies/{movieid}/genres/{genreid}/", s.attachGenre).Methods("PUT")
+	router.HandleFunc("/movies/{movieid}/genres/{genreid}/", s.detachGenre).Methods("DELETE")
+
+	// People and their filmography
+	router.HandleFunc("/people/", s.listPeople).Methods("GET")
+	router.HandleFunc("/people/", s.createPerson).Methods("POST")
+	router.HandleFunc("/people/{personid}/", s.getPerson).Methods("GET")
+	router.HandleFunc("/people/{personid}/", s.updatePerson).Methods("PUT")
+	router.HandleFunc("/people/{personid}/", s.deletePerson).Methods("DELETE")
+	router.HandleFunc("/people/{personid}/filmography/", s.getFilmography).Methods("GET")
+
+	// Credits of a movie
+	router.HandleFunc("/movies/{movieid}/credits/", s.listCredits).Methods("GET")
+	router.HandleFunc("/movies/{movieid}/credits/", s.addCredit).Methods("POST")
+	router.HandleFunc("/movies/{movieid}/credits/{creditid}/", s.deleteCredit).Methods("DELETE")
+
+	// Deleted movies waiting to be restored or purged
+	router.HandleFunc("/trash/", s.listTrash).Methods("GET")
+	router.HandleFunc("/trash/{movieid}/restore/", s.restoreMovie).Methods("POST")
+	router.HandleFunc("/trash/{movieid}/", s.purgeMovie).Methods("DELETE")
+
+	// Snapshots taken when all movies are deleted
+	router.HandleFunc("/snapshots/", s.listSnapshots).Methods("GET")
+	router.HandleFunc("/snapshots/{snapshotid}/restore/", s.restoreSnapshot).Methods("POST")
+	router.HandleFunc("/snapshots/{snapshotid}/", s.deleteSnapshot).Methods("DELETE")
+
+	// Audit log of every change
+	router.HandleFunc("/audit/", s.listAudit).Methods("GET")
 
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
+	return router
+}
+
+func main() {
+	loadEnv()
+
+	storeKind := flag.String("store", getEnv("MOVIES_STORE", ""), "storage backend: postgres, sqlite or memory (env MOVIES_STORE)")
+	databaseURL := flag.String("database-url", getEnv("DATABASE_URL", ""), "database URL whose scheme picks the backend, e.g. sqlite:movies.db (env DATABASE_URL)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [migrate up|down|status | import [-dry-run] [-best-effort] [-map column:field,...] file.csv | export [-format csv|ndjson] [-o file]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg, err := resolveStoreConfig(*storeKind, *databaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Subcommands run against the store and exit instead of serving
+	switch flag.Arg(0) {
+	case "":
+	case "migrate":
+		if cfg.kind == "memory" {
+			log.Fatalf("The memory store has no schema to migrate")
+		}
+
+		db := setupDB(cfg)
+		defer db.Close()
+
+		mg, err := newMigrator(db, cfg.dialect.name)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := runMigrate(context.Background(), mg, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "import":
+		store, err := setupStore(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		if err := runImport(context.Background(), store, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "export":
+		store, err := setupStore(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		if err := runExport(context.Background(), store, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Set up the store once and share it between all requests
+	store, err := setupStore(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	router := newRouter(newServer(store))
+
 	// Serve the app
 	fmt.Println("Server at 8080")
 	log.Fatal(http.ListenAndServe(":8080", router))
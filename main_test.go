@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Every handler logs the endpoint it serves
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testRouter serves the API from a fresh memory store
+func testRouter(t *testing.T) http.Handler {
+	t.Helper()
+	return newRouter(newServer(newMemoryStore()))
+}
+
+// serve sends a request to the router, with headers given as name, value pairs
+func serve(t *testing.T, router http.Handler, method string, target string, body string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request := httptest.NewRequest(method, target, reader)
+	request.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		request.Header.Set(headers[i], headers[i+1])
+	}
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// decodeMovies decodes a JsonResponse, failing the test when the status is not the expected one
+func decodeMovies(t *testing.T, recorder *httptest.ResponseRecorder, status int) JsonResponse {
+	t.Helper()
+
+	if recorder.Code != status {
+		t.Fatalf("got status %d, want %d: %s", recorder.Code, status, recorder.Body.String())
+	}
+
+	var response JsonResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("the response is not a JsonResponse: %v: %s", err, recorder.Body.String())
+	}
+	return response
+}
+
+// addMovies creates a movie for every movieid:moviename pair
+func addMovies(t *testing.T, router http.Handler, pairs ...string) {
+	t.Helper()
+
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, ":", 2)
+		body, _ := json.Marshal(Movie{MovieID: parts[0], MovieName: parts[1]})
+		decodeMovies(t, serve(t, router, "POST", "/addmovie/", string(body)), http.StatusCreated)
+	}
+}
+
+func TestCreateAndGetMovie(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+
+	response := decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", ""), http.StatusOK)
+	if len(response.Data) != 1 || response.Data[0].MovieName != "Alien" || response.Data[0].Version != 1 {
+		t.Fatalf("got %+v, want Alien at version 1", response.Data)
+	}
+
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Aliens"}`), http.StatusConflict)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m2"}`), http.StatusBadRequest)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m2","moviename":"Heat","rating":"X"}`), http.StatusBadRequest)
+}
+
+// Clients written before the optional fields existed send and read back only
+// movieid and moviename
+func TestMovieIDAndNameAloneStillWork(t *testing.T) {
+	router := testRouter(t)
+
+	recorder := serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien"}`)
+	decodeMovies(t, recorder, http.StatusCreated)
+	for _, field := range []string{"release_year", "runtime_minutes", "synopsis", "original_language", "rating", "deleted_at"} {
+		if strings.Contains(recorder.Body.String(), `"`+field+`"`) {
+			t.Errorf("the response holds %s, which the movie does not have: %s", field, recorder.Body.String())
+		}
+	}
+
+	response := decodeMovies(t, serve(t, router, "PUT", "/updatemovie/m1/", `{"movieid":"m1","moviename":"Aliens"}`), http.StatusOK)
+	if m := response.Data[0]; m.MovieID != "m1" || m.MovieName != "Aliens" {
+		t.Fatalf("got %+v, want m1 renamed Aliens", m)
+	}
+
+	response = decodeMovies(t, serve(t, router, "GET", "/movies/", ""), http.StatusOK)
+	if len(response.Data) != 1 || response.Data[0].MovieName != "Aliens" {
+		t.Fatalf("got %+v, want Aliens", response.Data)
+	}
+
+	response = decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m2"}`), http.StatusBadRequest)
+	if response.Message != errMissingIDOrName.Error() {
+		t.Fatalf("got message %q, want %q", response.Message, errMissingIDOrName.Error())
+	}
+}
+
+func TestGetMissingMovieSuggestsTitles(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "alien:Alien", "heat:Heat")
+
+	response := decodeMovies(t, serve(t, router, "GET", "/getmovie/alein/", ""), http.StatusNotFound)
+	if len(response.Suggestions) == 0 || response.Suggestions[0].MovieID != "alien" {
+		t.Fatalf("got suggestions %+v, want alien first", response.Suggestions)
+	}
+}
+
+func TestUpdateMovieChecksIfMatch(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+
+	etag := serve(t, router, "GET", "/getmovie/m1/", "").Header().Get("ETag")
+
+	recorder := serve(t, router, "PUT", "/updatemovie/m1/", `{"moviename":"Aliens"}`, "If-Match", etag)
+	response := decodeMovies(t, recorder, http.StatusOK)
+	if response.Data[0].MovieName != "Aliens" || response.Data[0].Version != 2 {
+		t.Fatalf("got %+v, want Aliens at version 2", response.Data[0])
+	}
+	if recorder.Header().Get("ETag") == etag {
+		t.Fatalf("the ETag did not change with the version")
+	}
+
+	// The tag the update was made with is now stale
+	decodeMovies(t, serve(t, router, "PUT", "/updatemovie/m1/", `{"moviename":"Alien 3"}`, "If-Match", etag), http.StatusPreconditionFailed)
+	decodeMovies(t, serve(t, router, "PUT", "/updatemovie/m1/", `{"movieid":"m2","moviename":"Alien 3"}`), http.StatusBadRequest)
+	decodeMovies(t, serve(t, router, "PUT", "/updatemovie/nope/", `{"moviename":"Alien 3"}`), http.StatusNotFound)
+}
+
+func TestPatchMovie(t *testing.T) {
+	router := testRouter(t)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien","synopsis":"In space","release_year":1979}`), http.StatusCreated)
+
+	response := decodeMovies(t, serve(t, router, "PATCH", "/updatemovie/m1/", `{"synopsis":null,"rating":"R"}`, "Content-Type", "application/merge-patch+json"), http.StatusOK)
+	m := response.Data[0]
+	if m.Synopsis != "" || m.Rating != "R" || m.ReleaseYear != 1979 || m.MovieName != "Alien" {
+		t.Fatalf("got %+v, want the synopsis cleared, the rating set and the rest kept", m)
+	}
+
+	decodeMovies(t, serve(t, router, "PATCH", "/updatemovie/m1/", `{"moviename":"Aliens"}`, "Content-Type", "text/plain"), http.StatusUnsupportedMediaType)
+	decodeMovies(t, serve(t, router, "PATCH", "/updatemovie/m1/", `{"movieid":"m2"}`), http.StatusBadRequest)
+	decodeMovies(t, serve(t, router, "PATCH", "/updatemovie/m1/", `{"moviename":null}`), http.StatusBadRequest)
+}
+
+func TestConditionalGetMovie(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+
+	etag := serve(t, router, "GET", "/getmovie/m1/", "").Header().Get("ETag")
+	if recorder := serve(t, router, "GET", "/getmovie/m1/", "", "If-None-Match", etag); recorder.Code != http.StatusNotModified {
+		t.Fatalf("got status %d, want 304 for the current ETag", recorder.Code)
+	}
+
+	serve(t, router, "PUT", "/updatemovie/m1/", `{"moviename":"Aliens"}`)
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", "", "If-None-Match", etag), http.StatusOK)
+}
+
+func TestETagOutlivesTheMovieID(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+	etag := serve(t, router, "GET", "/getmovie/m1/", "").Header().Get("ETag")
+
+	// The movie created again under the same movieid starts at version 1 too
+	serve(t, router, "DELETE", "/deletemovie/m1/", "")
+	serve(t, router, "DELETE", "/trash/m1/", "")
+	addMovies(t, router, "m1:Heat")
+
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", "", "If-None-Match", etag), http.StatusOK)
+	decodeMovies(t, serve(t, router, "DELETE", "/deletemovie/m1/", "", "If-Match", etag), http.StatusPreconditionFailed)
+}
+
+func TestDeleteRestoreAndPurgeMovie(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien", "m2:Heat")
+
+	decodeMovies(t, serve(t, router, "DELETE", "/deletemovie/m1/", ""), http.StatusOK)
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", ""), http.StatusNotFound)
+
+	trash := decodeMovies(t, serve(t, router, "GET", "/trash/", ""), http.StatusOK)
+	if len(trash.Data) != 1 || trash.Data[0].MovieID != "m1" || trash.Data[0].DeletedAt == nil {
+		t.Fatalf("got trash %+v, want m1 alone", trash.Data)
+	}
+
+	decodeMovies(t, serve(t, router, "POST", "/trash/m1/restore/", ""), http.StatusOK)
+	decodeMovies(t, serve(t, router, "GET", "/getmovie/m1/", ""), http.StatusOK)
+
+	decodeMovies(t, serve(t, router, "DELETE", "/deletemovie/m1/", ""), http.StatusOK)
+	decodeMovies(t, serve(t, router, "DELETE", "/trash/m1/", ""), http.StatusOK)
+	decodeMovies(t, serve(t, router, "DELETE", "/trash/m1/", ""), http.StatusNotFound)
+	decodeMovies(t, serve(t, router, "POST", "/trash/m1/restore/", ""), http.StatusNotFound)
+
+	// A purged movieid can be taken again
+	addMovies(t, router, "m1:Aliens")
+}
+
+func TestWritesIgnoreServerFields(t *testing.T) {
+	router := testRouter(t)
+	trashed := `"deleted_at":"2001-01-01T00:00:00Z","version":7,"updated_at":"2001-01-01T00:00:00Z"`
+
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien",`+trashed+`}`), http.StatusCreated)
+	decodeMovies(t, serve(t, router, "PUT", "/updatemovie/m1/", `{"moviename":"Aliens",`+trashed+`}`), http.StatusOK)
+
+	list := decodeMovies(t, serve(t, router, "GET", "/movies/", ""), http.StatusOK)
+	if len(list.Data) != 1 || list.Data[0].DeletedAt != nil || list.Data[0].Version != 2 || list.Data[0].UpdatedAt.Year() == 2001 {
+		t.Fatalf("got %+v, want Aliens at version 2 outside the trash", list.Data)
+	}
+
+	trash := decodeMovies(t, serve(t, router, "GET", "/trash/", ""), http.StatusOK)
+	if len(trash.Data) != 0 {
+		t.Fatalf("got trash %+v, want it empty", trash.Data)
+	}
+}
+
+func TestDeleteAllMoviesNeedsConfirmation(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien", "m2:Heat")
+
+	if recorder := serve(t, router, "DELETE", "/deletemovies/", ""); recorder.Code != http.StatusPreconditionRequired {
+		t.Fatalf("got status %d, want 428 without the confirmation header", recorder.Code)
+	}
+
+	var snapshots SnapshotResponse
+	recorder := serve(t, router, "DELETE", "/deletemovies/", "", confirmDeleteHeader, confirmDeleteAll)
+	if err := json.Unmarshal(recorder.Body.Bytes(), &snapshots); err != nil || recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if len(snapshots.Data) != 1 || snapshots.Data[0].MovieCount != 2 {
+		t.Fatalf("got %+v, want a snapshot of 2 movies", snapshots.Data)
+	}
+
+	list := decodeMovies(t, serve(t, router, "GET", "/movies/", ""), http.StatusOK)
+	if len(list.Data) != 0 {
+		t.Fatalf("got %d movies after deleting them all", len(list.Data))
+	}
+
+	decodeMovies(t, serve(t, router, "POST", "/snapshots/"+snapshots.Data[0].SnapshotID+"/restore/", ""), http.StatusOK)
+	list = decodeMovies(t, serve(t, router, "GET", "/movies/", ""), http.StatusOK)
+	if len(list.Data) != 2 {
+		t.Fatalf("got %d movies after restoring the snapshot, want 2", len(list.Data))
+	}
+}
+
+func TestFiltersLeaveOutMissingValues(t *testing.T) {
+	router := testRouter(t)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien","release_year":1979,"runtime_minutes":117,"rating":"R"}`), http.StatusCreated)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m2","moviename":"Heat","rating":"R"}`), http.StatusCreated)
+
+	for _, query := range []string{"release_year_max=1990", "runtime_minutes_max=120", "release_year_min=0", "rating=R&release_year_max=1980"} {
+		list := decodeMovies(t, serve(t, router, "GET", "/movies/?"+query, ""), http.StatusOK)
+		if movieIDs(list.Data) != "m1" {
+			t.Errorf("%s: got %s, want m1 alone", query, movieIDs(list.Data))
+		}
+	}
+
+	// Sorting still places the movies without a value first
+	list := decodeMovies(t, serve(t, router, "GET", "/movies/?sort=release_year", ""), http.StatusOK)
+	if movieIDs(list.Data) != "m2,m1" {
+		t.Errorf("got %s, want m2,m1", movieIDs(list.Data))
+	}
+}
+
+func TestListMoviesRefusesUnknownParameters(t *testing.T) {
+	router := testRouter(t)
+
+	response := decodeMovies(t, serve(t, router, "GET", "/movies/?limit=0&colour=red", ""), http.StatusBadRequest)
+	if len(response.Errors) != 2 {
+		t.Fatalf("got errors %+v, want limit and colour refused", response.Errors)
+	}
+}
+
+func TestAuditRecordsChanges(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien")
+	serve(t, router, "PUT", "/updatemovie/m1/", `{"moviename":"Aliens"}`, actorHeader, "ripley")
+
+	var audit AuditResponse
+	recorder := serve(t, router, "GET", "/audit/?movieid=m1", "")
+	if err := json.Unmarshal(recorder.Body.Bytes(), &audit); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(audit.Data) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(audit.Data))
+	}
+	if update := audit.Data[0]; update.Action != "update" || update.Actor != "ripley" || update.Before == nil || update.After == nil {
+		t.Fatalf("got %+v, want the update by ripley first", update)
+	}
+	if create := audit.Data[1]; create.Action != "create" || create.Actor != anonymousActor {
+		t.Fatalf("got %+v, want the anonymous create last", create)
+	}
+}
+
+// unauditedStore is a store whose audit log cannot be written
+type unauditedStore struct {
+	Store
+}
+
+func (unauditedStore) RecordAudit(ctx context.Context, entries ...AuditEntry) error {
+	return errors.New("the audit log is unavailable")
+}
+
+func (s unauditedStore) Atomically(ctx context.Context, fn func(Store) error) error {
+	return s.Store.Atomically(ctx, func(store Store) error {
+		return fn(unauditedStore{store})
+	})
+}
+
+func TestUnrecordedChangeFailsTheRequest(t *testing.T) {
+	stores := map[string]Store{
+		"memory": newMemoryStore(),
+		"sqlite": testSQLiteStore(t),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			router := newRouter(newServer(unauditedStore{store}))
+
+			response := decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien"}`), http.StatusInternalServerError)
+			if response.Message != notAuditedMessage {
+				t.Fatalf("got message %q, want %q", response.Message, notAuditedMessage)
+			}
+
+			// The change is discarded along with its audit entry
+			if _, err := store.Get(context.Background(), "m1"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("got %v, want the movie not to be created", err)
+			}
+		})
+	}
+}
+
+func TestPurgeRecordsTheMovie(t *testing.T) {
+	router := testRouter(t)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"m1","moviename":"Alien","synopsis":"In space"}`), http.StatusCreated)
+	serve(t, router, "DELETE", "/deletemovie/m1/", "")
+	decodeMovies(t, serve(t, router, "DELETE", "/trash/m1/", ""), http.StatusOK)
+
+	var audit AuditResponse
+	if err := json.Unmarshal(serve(t, router, "GET", "/audit/?movieid=m1&limit=1", "").Body.Bytes(), &audit); err != nil {
+		t.Fatal(err)
+	}
+
+	var before Movie
+	if len(audit.Data) != 1 || audit.Data[0].Action != "purge" || json.Unmarshal(audit.Data[0].Before, &before) != nil {
+		t.Fatalf("got %+v, want the purge with the movie before it", audit.Data)
+	}
+	if before.MovieName != "Alien" || before.Synopsis != "In space" || before.DeletedAt == nil {
+		t.Fatalf("got %+v recorded before the purge, want the movie in the trash", before)
+	}
+}
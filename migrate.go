@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations live in migrations/<dialect>/ and are named
+// <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// migrationStatus describes whether a migration has been applied to the database
+type migrationStatus struct {
+	version   int
+	name      string
+	appliedAt *time.Time
+}
+
+// migrator applies the embedded migrations for one dialect and records them
+// in the schema_migrations table
+type migrator struct {
+	db         *sql.DB
+	migrations []migration
+}
+
+func newMigrator(db *sql.DB, dialect string) (*migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, path.Join("migrations", dialect))
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrator{db: db, migrations: migrations}, nil
+}
+
+// Read the up and down scripts in dir and pair them up by version
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>", fileName)
+		}
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", fileName, err)
+		}
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+
+		if direction == "up" {
+			m.up = string(contents)
+		} else {
+			m.down = string(contents)
+		}
+	}
+
+	var migrations []migration
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+	return migrations, nil
+}
+
+// Latest returns the version of the newest embedded migration
+func (mg *migrator) Latest() int {
+	if len(mg.migrations) == 0 {
+		return 0
+	}
+	return mg.migrations[len(mg.migrations)-1].version
+}
+
+func (mg *migrator) ensureTable(ctx context.Context) error {
+	_, err := mg.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// Return the time each applied migration was applied at, keyed by version
+func (mg *migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if err := mg.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := mg.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Version returns the newest migration applied to the database, or 0
+func (mg *migrator) Version(ctx context.Context) (int, error) {
+	applied, err := mg.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+
+	return version, nil
+}
+
+// Up applies every pending migration in order and returns the ones it applied
+func (mg *migrator) Up(ctx context.Context) ([]migration, error) {
+	applied, err := mg.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []migration
+	for _, m := range mg.migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+
+		err := mg.inTx(ctx, m.up, "INSERT INTO schema_migrations(version, name) VALUES($1, $2)", m.version, m.name)
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the newest applied migration and returns it, or returns
+// false if there is nothing to roll back
+func (mg *migrator) Down(ctx context.Context) (migration, bool, error) {
+	applied, err := mg.applied(ctx)
+	if err != nil {
+		return migration{}, false, err
+	}
+
+	for i := len(mg.migrations) - 1; i >= 0; i-- {
+		m := mg.migrations[i]
+		if _, ok := applied[m.version]; !ok {
+			continue
+		}
+
+		err := mg.inTx(ctx, m.down, "DELETE FROM schema_migrations WHERE version = $1", m.version)
+		if err != nil {
+			return m, false, fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+		return m, true, nil
+	}
+
+	return migration{}, false, nil
+}
+
+// Status lists every embedded migration and when it was applied
+func (mg *migrator) Status(ctx context.Context) ([]migrationStatus, error) {
+	applied, err := mg.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []migrationStatus
+	for _, m := range mg.migrations {
+		status := migrationStatus{version: m.version, name: m.name}
+		if appliedAt, ok := applied[m.version]; ok {
+			status.appliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Run a migration script and its bookkeeping statement in one transaction
+func (mg *migrator) inTx(ctx context.Context, script string, bookkeeping string, args ...interface{}) error {
+	tx, err := mg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkSchema refuses to let the server start against a database whose
+// schema is older than the migrations embedded in this build
+func checkSchema(ctx context.Context, mg *migrator) error {
+	version, err := mg.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if version < mg.Latest() {
+		return fmt.Errorf("database schema is at version %d but version %d is required, run the migrate up subcommand", version, mg.Latest())
+	}
+
+	return nil
+}
+
+// runMigrate implements the migrate up, down and status subcommands
+func runMigrate(ctx context.Context, mg *migrator, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: migrate up|down|status")
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := mg.Up(ctx)
+		for _, m := range done {
+			fmt.Printf("Applied %04d_%s\n", m.version, m.name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("Schema is already up to date")
+		}
+	case "down":
+		m, ok, err := mg.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("No migrations to roll back")
+		} else {
+			fmt.Printf("Rolled back %04d_%s\n", m.version, m.name)
+		}
+	case "status":
+		statuses, err := mg.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.appliedAt != nil {
+				state = "applied " + status.appliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.version, status.name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,6 @@
+-- IF NOT EXISTS adopts databases where the table was created by hand
+CREATE TABLE IF NOT EXISTS movies (
+    id SERIAL PRIMARY KEY,
+    movieid TEXT NOT NULL UNIQUE,
+    moviename TEXT NOT NULL
+);
@@ -0,0 +1,6 @@
+ALTER TABLE movies
+    DROP COLUMN release_year,
+    DROP COLUMN runtime_minutes,
+    DROP COLUMN synopsis,
+    DROP COLUMN original_language,
+    DROP COLUMN rating;
@@ -0,0 +1,6 @@
+ALTER TABLE movies
+    ADD COLUMN release_year INTEGER,
+    ADD COLUMN runtime_minutes INTEGER,
+    ADD COLUMN synopsis TEXT,
+    ADD COLUMN original_language TEXT,
+    ADD COLUMN rating TEXT;
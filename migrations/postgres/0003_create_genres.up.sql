@@ -0,0 +1,13 @@
+CREATE TABLE genres (
+    id SERIAL PRIMARY KEY,
+    genreid TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE movie_genres (
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    genre_id INTEGER NOT NULL REFERENCES genres(id) ON DELETE CASCADE,
+    PRIMARY KEY (movie_id, genre_id)
+);
+
+CREATE INDEX movie_genres_genre_id_idx ON movie_genres(genre_id);
@@ -0,0 +1,2 @@
+DROP TABLE credits;
+DROP TABLE people;
@@ -0,0 +1,19 @@
+CREATE TABLE people (
+    id SERIAL PRIMARY KEY,
+    personid TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL
+);
+
+-- character_name is '' rather than NULL for non-actors so the unique
+-- constraint also covers directors and writers
+CREATE TABLE credits (
+    id SERIAL PRIMARY KEY,
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    person_id INTEGER NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('director', 'writer', 'actor')),
+    character_name TEXT NOT NULL DEFAULT '',
+    billing_order INTEGER,
+    UNIQUE (movie_id, person_id, role, character_name)
+);
+
+CREATE INDEX credits_person_id_idx ON credits(person_id);
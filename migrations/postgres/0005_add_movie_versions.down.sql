@@ -0,0 +1,3 @@
+ALTER TABLE movies
+    DROP COLUMN version,
+    DROP COLUMN updated_at;
@@ -0,0 +1,2 @@
+-- A movie with deleted_at set is in the trash and hidden from every other read
+ALTER TABLE movies ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
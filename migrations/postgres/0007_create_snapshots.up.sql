@@ -0,0 +1,9 @@
+-- A snapshot holds the movies removed by deleting all movies, with their
+-- genres and credits, as JSON so that the wipe can be undone
+CREATE TABLE snapshots (
+    id SERIAL PRIMARY KEY,
+    snapshotid TEXT NOT NULL UNIQUE,
+    movie_count INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    data TEXT NOT NULL
+);
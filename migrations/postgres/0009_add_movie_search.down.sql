@@ -0,0 +1,2 @@
+DROP INDEX movies_search_vector_idx;
+ALTER TABLE movies DROP COLUMN search_vector;
@@ -0,0 +1,7 @@
+-- Names weigh more than synopses when ranking search results
+ALTER TABLE movies ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', moviename), 'A') ||
+    setweight(to_tsvector('english', COALESCE(synopsis, '')), 'B')
+) STORED;
+
+CREATE INDEX movies_search_vector_idx ON movies USING GIN (search_vector);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS movies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    movieid TEXT NOT NULL UNIQUE,
+    moviename TEXT NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE movies DROP COLUMN release_year;
+ALTER TABLE movies DROP COLUMN runtime_minutes;
+ALTER TABLE movies DROP COLUMN synopsis;
+ALTER TABLE movies DROP COLUMN original_language;
+ALTER TABLE movies DROP COLUMN rating;
@@ -0,0 +1,5 @@
+ALTER TABLE movies ADD COLUMN release_year INTEGER;
+ALTER TABLE movies ADD COLUMN runtime_minutes INTEGER;
+ALTER TABLE movies ADD COLUMN synopsis TEXT;
+ALTER TABLE movies ADD COLUMN original_language TEXT;
+ALTER TABLE movies ADD COLUMN rating TEXT;
@@ -0,0 +1,6 @@
+ALTER TABLE movies ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- SQLite cannot add a column with a CURRENT_TIMESTAMP default, so existing
+-- rows are backfilled and the store always sets updated_at itself
+ALTER TABLE movies ADD COLUMN updated_at TIMESTAMP;
+UPDATE movies SET updated_at = CURRENT_TIMESTAMP;
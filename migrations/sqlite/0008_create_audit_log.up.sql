@@ -0,0 +1,15 @@
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    -- Not a foreign key, the log outlives the movies it mentions
+    movieid TEXT,
+    before_data TEXT,
+    after_data TEXT,
+    request_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_log_movieid_idx ON audit_log(movieid);
+CREATE INDEX audit_log_actor_idx ON audit_log(actor);
+CREATE INDEX audit_log_created_at_idx ON audit_log(created_at);
@@ -0,0 +1,4 @@
+DROP TRIGGER movies_fts_update;
+DROP TRIGGER movies_fts_delete;
+DROP TRIGGER movies_fts_insert;
+DROP TABLE movies_fts;
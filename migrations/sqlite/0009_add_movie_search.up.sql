@@ -0,0 +1,24 @@
+-- SQLite has no tsvector, so movies are indexed by an FTS5 table that reads
+-- its text from movies and is kept up to date by triggers
+CREATE VIRTUAL TABLE movies_fts USING fts5(
+    moviename,
+    synopsis,
+    content = 'movies',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO movies_fts(movies_fts) VALUES ('rebuild');
+
+CREATE TRIGGER movies_fts_insert AFTER INSERT ON movies BEGIN
+    INSERT INTO movies_fts(rowid, moviename, synopsis) VALUES (new.id, new.moviename, new.synopsis);
+END;
+
+CREATE TRIGGER movies_fts_delete AFTER DELETE ON movies BEGIN
+    INSERT INTO movies_fts(movies_fts, rowid, moviename, synopsis) VALUES ('delete', old.id, old.moviename, old.synopsis);
+END;
+
+CREATE TRIGGER movies_fts_update AFTER UPDATE OF moviename, synopsis ON movies BEGIN
+    INSERT INTO movies_fts(movies_fts, rowid, moviename, synopsis) VALUES ('delete', old.id, old.moviename, old.synopsis);
+    INSERT INTO movies_fts(rowid, moviename, synopsis) VALUES (new.id, new.moviename, new.synopsis);
+END;
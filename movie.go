@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Content ratings accepted for Movie.Rating, following the MPA rating system
+var contentRatings = []string{"G", "PG", "PG-13", "R", "NC-17", "NR"}
+
+const (
+	// The first motion pictures date from 1888
+	minReleaseYear = 1888
+	// Movies can be catalogued a few years ahead of their release
+	releaseYearLookahead = 10
+	maxRuntimeMinutes    = 1000
+	maxSynopsisLength    = 2000
+)
+
+// errMissingIDOrName is what createMovie has always answered when either of the required fields is empty
+var errMissingIDOrName = errors.New("You are missing movieID or movieName")
+
+// validate checks a movie before it is written to the store. Every field
+// other than movieid and moviename is optional and left out when zero.
+func (m Movie) validate() error {
+	if m.MovieID == "" || m.MovieName == "" {
+		return errMissingIDOrName
+	}
+
+	if m.ReleaseYear != 0 {
+		maxYear := time.Now().Year() + releaseYearLookahead
+		if m.ReleaseYear < minReleaseYear || m.ReleaseYear > maxYear {
+			return fmt.Errorf("release_year must be between %d and %d", minReleaseYear, maxYear)
+		}
+	}
+
+	if m.RuntimeMinutes < 0 || m.RuntimeMinutes > maxRuntimeMinutes {
+		return fmt.Errorf("runtime_minutes must be between 1 and %d", maxRuntimeMinutes)
+	}
+
+	if utf8.RuneCountInString(m.Synopsis) > maxSynopsisLength {
+		return fmt.Errorf("synopsis must be at most %d characters", maxSynopsisLength)
+	}
+
+	if m.OriginalLanguage != "" && !isLanguageCode(m.OriginalLanguage) {
+		return errors.New("original_language must be a two letter lowercase ISO 639-1 code, e.g. en")
+	}
+
+	if m.Rating != "" && !isContentRating(m.Rating) {
+		return fmt.Errorf("rating must be one of %s", strings.Join(contentRatings, ", "))
+	}
+
+	return nil
+}
+
+func isLanguageCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+
+	for _, c := range code {
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isContentRating(rating string) bool {
+	for _, r := range contentRatings {
+		if r == rating {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyMoviePatch merges a JSON Merge Patch into m and validates the result.
+// The movieid and genres cannot be changed through a patch.
+func applyMoviePatch(m Movie, patch map[string]interface{}) (Movie, error) {
+	if value, ok := patch["movieid"]; ok && value != m.MovieID {
+		return Movie{}, errors.New("The movieid of a movie cannot be changed")
+	}
+
+	if _, ok := patch["genres"]; ok {
+		return Movie{}, errors.New("Genres are changed through /movies/{movieid}/genres/{genreid}/")
+	}
+
+	for _, field := range []string{"version", "updated_at", "deleted_at"} {
+		if _, ok := patch[field]; ok {
+			return Movie{}, fmt.Errorf("%s is maintained by the server and cannot be patched", field)
+		}
+	}
+
+	original, err := json.Marshal(m)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	var target interface{}
+	if err := json.Unmarshal(original, &target); err != nil {
+		return Movie{}, err
+	}
+
+	merged, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return Movie{}, err
+	}
+
+	// Unknown fields are most likely typos, so they are refused rather than dropped
+	var patched Movie
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return Movie{}, fmt.Errorf("The patch does not fit a movie: %v", err)
+	}
+
+	patched.ID = m.ID
+
+	return patched, patched.validate()
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// movieField is a movie field that /movies/ can sort and filter on. Only
+// these fields reach the stores, so no raw SQL comes from the query string.
+type movieField struct {
+	// column is the SQL expression the field is sorted on. Optional fields
+	// are coalesced to the zero value they show as in JSON, so that every
+	// store orders them the same way.
+	column string
+	// filterColumn is the column conditions compare. Optional fields are
+	// compared raw, so that a movie without a value, stored as NULL, matches
+	// no condition on the field rather than matching as if it was zero.
+	filterColumn string
+	// optional fields are not set when they are zero
+	optional bool
+	numeric  bool
+	value    func(m Movie) interface{}
+}
+
+var movieFields = map[string]movieField{
+	"id":                {column: "id", filterColumn: "id", numeric: true, value: func(m Movie) interface{} { return m.ID }},
+	"movieid":           {column: "movieid", filterColumn: "movieid", value: func(m Movie) interface{} { return m.MovieID }},
+	"moviename":         {column: "moviename", filterColumn: "moviename", value: func(m Movie) interface{} { return m.MovieName }},
+	"release_year":      {column: "COALESCE(release_year, 0)", filterColumn: "release_year", optional: true, numeric: true, value: func(m Movie) interface{} { return m.ReleaseYear }},
+	"runtime_minutes":   {column: "COALESCE(runtime_minutes, 0)", filterColumn: "runtime_minutes", optional: true, numeric: true, value: func(m Movie) interface{} { return m.RuntimeMinutes }},
+	"original_language": {column: "COALESCE(original_language, '')", filterColumn: "original_language", optional: true, value: func(m Movie) interface{} { return m.OriginalLanguage }},
+	"rating":            {column: "COALESCE(rating, '')", filterColumn: "rating", optional: true, value: func(m Movie) interface{} { return m.Rating }},
+}
+
+// isSet reports whether m has a value for the field, which it always has
+// unless the field is optional
+func (f movieField) isSet(m Movie) bool {
+	if !f.optional {
+		return true
+	}
+
+	value := f.value(m)
+	return value != 0 && value != ""
+}
+
+// SortKey orders movies by a field of movieFields
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Operators of a MovieCondition
+const (
+	opEqual  = "="
+	opPrefix = "prefix"
+	opMin    = ">="
+	opMax    = "<="
+	opIn     = "in"
+)
+
+// MovieCondition keeps the movies whose field compares to Value with Op.
+// Value is an int for numeric fields and a string otherwise, or a []string
+// of the values to keep for opIn. Prefixes are matched case-insensitively.
+type MovieCondition struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+// movieFilters maps the filter parameters of /movies/ to the condition
+// they add
+var movieFilters = map[string]MovieCondition{
+	"moviename":           {Field: "moviename", Op: opEqual},
+	"name_prefix":         {Field: "moviename", Op: opPrefix},
+	"release_year":        {Field: "release_year", Op: opEqual},
+	"release_year_min":    {Field: "release_year", Op: opMin},
+	"release_year_max":    {Field: "release_year", Op: opMax},
+	"runtime_minutes":     {Field: "runtime_minutes", Op: opEqual},
+	"runtime_minutes_min": {Field: "runtime_minutes", Op: opMin},
+	"runtime_minutes_max": {Field: "runtime_minutes", Op: opMax},
+	"original_language":   {Field: "original_language", Op: opEqual},
+	"rating":              {Field: "rating", Op: opEqual},
+}
+
+// listParams are the other parameters /movies/ understands
+var listParams = []string{"limit", "offset", "cursor", "sort", "genre", "fields"}
+
+// ParamError reports a query parameter that was refused
+type ParamError struct {
+	Param   string `json:"param"`
+	Message string `json:"message"`
+}
+
+// orderBy is the full sort order of q. The id is always the last key, so
+// that no two movies sort the same and keyset paging never skips a movie.
+func (q MovieQuery) orderBy() []SortKey {
+	keys := append([]SortKey{}, q.Sort...)
+	for _, key := range keys {
+		if key.Field == "id" {
+			return keys
+		}
+	}
+	return append(keys, SortKey{Field: "id"})
+}
+
+// sortValues returns the values of m for each key, as kept in a cursor
+func sortValues(m Movie, keys []SortKey) []interface{} {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		values[i] = movieFields[key.Field].value(m)
+	}
+	return values
+}
+
+// parseMovieList reads the query string of /movies/. Every problem is
+// reported, and parameters it does not know are refused rather than ignored.
+func parseMovieList(query url.Values) (pageRequest, []ParamError) {
+	var errs []ParamError
+	var filter MovieQuery
+
+	for _, param := range sortedParams(query) {
+		if _, ok := movieFilters[param]; !ok && !contains(listParams, param) {
+			errs = append(errs, ParamError{Param: param, Message: "Unknown parameter"})
+		}
+	}
+
+	if value := query.Get("sort"); value != "" {
+		keys, err := parseSort(value)
+		if err != nil {
+			errs = append(errs, ParamError{Param: "sort", Message: err.Error()})
+		}
+		filter.Sort = keys
+	}
+
+	errs = append(errs, parseFilters(query, &filter)...)
+
+	fields, err := parseFields(query.Get("fields"))
+	if err != nil {
+		errs = append(errs, ParamError{Param: "fields", Message: err.Error()})
+	}
+	filter.Fields = fields
+
+	page, pageErrs := parsePage(query, filter)
+	return page, append(errs, pageErrs...)
+}
+
+// parseSort reads a sort parameter like "moviename,-id", where a leading
+// minus sorts that field in descending order
+func parseSort(value string) ([]SortKey, error) {
+	var keys []SortKey
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(value, ",") {
+		key := SortKey{Field: strings.TrimSpace(part)}
+		if strings.HasPrefix(key.Field, "-") {
+			key.Field, key.Desc = key.Field[1:], true
+		}
+
+		if _, ok := movieFields[key.Field]; !ok {
+			return nil, fmt.Errorf("Cannot sort by %q, use one of %s", key.Field, strings.Join(fieldNames(), ", "))
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%s is sorted on twice", key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// formatSort is the inverse of parseSort
+func formatSort(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Field
+		if key.Desc {
+			parts[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseFilters adds a condition to q for each filter parameter in query
+func parseFilters(query url.Values, q *MovieQuery) []ParamError {
+	var errs []ParamError
+
+	for _, param := range sortedParams(query) {
+		condition, ok := movieFilters[param]
+		if !ok {
+			continue
+		}
+
+		value := query.Get(param)
+		if movieFields[condition.Field].numeric {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, ParamError{Param: param, Message: param + " must be a whole number"})
+				continue
+			}
+			condition.Value = n
+		} else if value == "" {
+			errs = append(errs, ParamError{Param: param, Message: param + " cannot be empty"})
+			continue
+		} else {
+			condition.Value = value
+		}
+
+		q.Conditions = append(q.Conditions, condition)
+	}
+
+	q.Genre = query.Get("genre")
+
+	return errs
+}
+
+// sortedParams returns the names of the parameters in query, so that
+// they are checked in a stable order
+func sortedParams(query url.Values) []string {
+	params := make([]string, 0, len(query))
+	for param := range query {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	return params
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(movieFields))
+	for name := range movieFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateMovie(t *testing.T) {
+	maxYear := time.Now().Year() + releaseYearLookahead
+
+	tests := []struct {
+		name  string
+		movie Movie
+		// wantErr is part of the error, or empty when the movie is valid
+		wantErr string
+	}{
+		{name: "movieid and moviename alone", movie: Movie{MovieID: "m1", MovieName: "Alien"}},
+		{name: "no movieid", movie: Movie{MovieName: "Alien"}, wantErr: "missing movieID or movieName"},
+		{name: "no moviename", movie: Movie{MovieID: "m1"}, wantErr: "missing movieID or movieName"},
+
+		{name: "first year", movie: Movie{MovieID: "m1", MovieName: "Alien", ReleaseYear: minReleaseYear}},
+		{name: "year before the first", movie: Movie{MovieID: "m1", MovieName: "Alien", ReleaseYear: minReleaseYear - 1}, wantErr: "release_year must be between"},
+		{name: "last year ahead", movie: Movie{MovieID: "m1", MovieName: "Alien", ReleaseYear: maxYear}},
+		{name: "year too far ahead", movie: Movie{MovieID: "m1", MovieName: "Alien", ReleaseYear: maxYear + 1}, wantErr: "release_year must be between"},
+
+		{name: "shortest runtime", movie: Movie{MovieID: "m1", MovieName: "Alien", RuntimeMinutes: 1}},
+		{name: "longest runtime", movie: Movie{MovieID: "m1", MovieName: "Alien", RuntimeMinutes: maxRuntimeMinutes}},
+		{name: "negative runtime", movie: Movie{MovieID: "m1", MovieName: "Alien", RuntimeMinutes: -1}, wantErr: "runtime_minutes must be between"},
+		{name: "runtime too long", movie: Movie{MovieID: "m1", MovieName: "Alien", RuntimeMinutes: maxRuntimeMinutes + 1}, wantErr: "runtime_minutes must be between"},
+
+		{name: "longest synopsis", movie: Movie{MovieID: "m1", MovieName: "Alien", Synopsis: strings.Repeat("é", maxSynopsisLength)}},
+		{name: "synopsis too long", movie: Movie{MovieID: "m1", MovieName: "Alien", Synopsis: strings.Repeat("a", maxSynopsisLength+1)}, wantErr: "synopsis must be at most"},
+
+		{name: "known rating", movie: Movie{MovieID: "m1", MovieName: "Alien", Rating: "NC-17"}},
+		{name: "unknown rating", movie: Movie{MovieID: "m1", MovieName: "Alien", Rating: "X"}, wantErr: "rating must be one of"},
+		{name: "lowercase rating", movie: Movie{MovieID: "m1", MovieName: "Alien", Rating: "pg"}, wantErr: "rating must be one of"},
+
+		{name: "language code", movie: Movie{MovieID: "m1", MovieName: "Alien", OriginalLanguage: "en"}},
+		{name: "uppercase language code", movie: Movie{MovieID: "m1", MovieName: "Alien", OriginalLanguage: "EN"}, wantErr: "original_language must be"},
+		{name: "three letter language code", movie: Movie{MovieID: "m1", MovieName: "Alien", OriginalLanguage: "eng"}, wantErr: "original_language must be"},
+		{name: "language code with a digit", movie: Movie{MovieID: "m1", MovieName: "Alien", OriginalLanguage: "e1"}, wantErr: "original_language must be"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.movie.validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyMoviePatch(t *testing.T) {
+	updatedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	original := Movie{
+		ID:          7,
+		MovieID:     "m1",
+		MovieName:   "Alien",
+		ReleaseYear: 1979,
+		Synopsis:    "In space",
+		Rating:      "R",
+		Genres:      []Genre{{GenreID: "horror", Name: "Horror"}},
+		Version:     3,
+		UpdatedAt:   updatedAt,
+	}
+
+	tests := []struct {
+		name    string
+		patch   map[string]interface{}
+		check   func(Movie) bool
+		wantErr bool
+	}{
+		{
+			name:  "sets a field and keeps the others",
+			patch: map[string]interface{}{"runtime_minutes": float64(117)},
+			check: func(m Movie) bool {
+				return m.RuntimeMinutes == 117 && m.MovieName == "Alien" && m.ReleaseYear == 1979 && m.Synopsis == "In space"
+			},
+		},
+		{
+			name:  "clears a field set to null",
+			patch: map[string]interface{}{"synopsis": nil, "rating": nil},
+			check: func(m Movie) bool { return m.Synopsis == "" && m.Rating == "" && m.MovieName == "Alien" },
+		},
+		{
+			name:  "keeps the row ID, version and genres",
+			patch: map[string]interface{}{"moviename": "Aliens"},
+			check: func(m Movie) bool {
+				return m.ID == 7 && m.MovieName == "Aliens" && m.Version == 3 && len(m.Genres) == 1 && m.UpdatedAt.Equal(updatedAt)
+			},
+		},
+		{
+			name:  "accepts the movieid it already has",
+			patch: map[string]interface{}{"movieid": "m1"},
+			check: func(m Movie) bool { return m.MovieID == "m1" },
+		},
+		{name: "refuses another movieid", patch: map[string]interface{}{"movieid": "m2"}, wantErr: true},
+		{name: "refuses genres", patch: map[string]interface{}{"genres": []interface{}{}}, wantErr: true},
+		{name: "refuses the version", patch: map[string]interface{}{"version": float64(9)}, wantErr: true},
+		{name: "refuses deleted_at", patch: map[string]interface{}{"deleted_at": nil}, wantErr: true},
+		{name: "refuses unknown fields", patch: map[string]interface{}{"directr": "Scott"}, wantErr: true},
+		{name: "refuses a field of the wrong type", patch: map[string]interface{}{"release_year": "1979"}, wantErr: true},
+		{name: "refuses clearing the name", patch: map[string]interface{}{"moviename": nil}, wantErr: true},
+		{name: "refuses an invalid rating", patch: map[string]interface{}{"rating": "X"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := applyMoviePatch(original, test.patch)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", m)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if !test.check(m) {
+				t.Fatalf("got %+v", m)
+			}
+		})
+	}
+}
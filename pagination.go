@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ListMeta describes the page of movies in a response
+type ListMeta struct {
+	// Total is how many movies there are across every page
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// movieCursor is a position in the movie listing. Clients get it as opaque
+// base64, so what it holds can change.
+type movieCursor struct {
+	// Sort is the sort order the cursor was given out for, and Values the
+	// sort key values of the movie it points at, ending with its id
+	Sort   string        `json:"sort,omitempty"`
+	Values []interface{} `json:"values"`
+	// Before pages backwards, to the movies just before the one pointed at
+	Before bool `json:"before,omitempty"`
+}
+
+func newCursor(m Movie, q MovieQuery, before bool) movieCursor {
+	return movieCursor{Sort: formatSort(q.Sort), Values: sortValues(m, q.orderBy()), Before: before}
+}
+
+func (c movieCursor) encode() string {
+	data, err := json.Marshal(c)
+	checkErr(err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor given out for the sort order of q, turning
+// its values back into the types of their fields
+func decodeCursor(value string, q MovieQuery) (movieCursor, error) {
+	var c movieCursor
+	invalid := errors.New("cursor is not one given out by this server")
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return movieCursor{}, invalid
+	}
+
+	if c.Sort != formatSort(q.Sort) {
+		return movieCursor{}, errors.New("cursor was given out for another sort order")
+	}
+
+	keys := q.orderBy()
+	if len(c.Values) != len(keys) {
+		return movieCursor{}, invalid
+	}
+
+	for i, key := range keys {
+		switch v := c.Values[i].(type) {
+		case float64:
+			if !movieFields[key.Field].numeric {
+				return movieCursor{}, invalid
+			}
+			c.Values[i] = int(v)
+		case string:
+			if movieFields[key.Field].numeric {
+				return movieCursor{}, invalid
+			}
+		default:
+			return movieCursor{}, invalid
+		}
+	}
+
+	return c, nil
+}
+
+// pageRequest is the paging asked for in the query string of /movies/
+type pageRequest struct {
+	// paged is false when no paging parameter was given, and every movie is listed
+	paged  bool
+	limit  int
+	offset int
+	cursor *movieCursor
+	// filter holds the filters and sort order, which apply to every page
+	filter MovieQuery
+}
+
+func parsePage(query url.Values, filter MovieQuery) (pageRequest, []ParamError) {
+	p := pageRequest{limit: defaultPageSize, filter: filter}
+	var errs []ParamError
+
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageSize {
+			errs = append(errs, ParamError{Param: "limit", Message: fmt.Sprintf("limit must be a number from 1 to %d", maxPageSize)})
+		}
+		p.limit, p.paged = n, true
+	}
+
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			errs = append(errs, ParamError{Param: "offset", Message: "offset must be a number of 0 or more"})
+		}
+		p.offset, p.paged = n, true
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		if query.Get("offset") != "" {
+			errs = append(errs, ParamError{Param: "cursor", Message: "Use either offset or cursor, not both"})
+		}
+		c, err := decodeCursor(value, filter)
+		if err != nil {
+			errs = append(errs, ParamError{Param: "cursor", Message: err.Error()})
+		}
+		p.cursor, p.paged = &c, true
+	}
+
+	return p, errs
+}
+
+// query selects the page, plus one movie to tell whether there is another
+// page after it, or before it when paging backwards
+func (p pageRequest) query() MovieQuery {
+	q := p.filter
+	if !p.paged {
+		return q
+	}
+
+	q.Limit, q.Offset = p.limit+1, p.offset
+	if p.cursor != nil && p.cursor.Before {
+		q.Before = p.cursor.Values
+	} else if p.cursor != nil {
+		q.After = p.cursor.Values
+	}
+
+	return q
+}
+
+// paginate trims the movies fetched with query to the page, and describes
+// the pages around it both as metadata and as a Link header. Links follow
+// the mode of the request, while cursors are always given.
+func (p pageRequest) paginate(movies []Movie, total int, u *url.URL) ([]Movie, ListMeta, string) {
+	if !p.paged {
+		return movies, ListMeta{Total: len(movies)}, ""
+	}
+
+	backwards := p.cursor != nil && p.cursor.Before
+	more := len(movies) > p.limit
+	if more && backwards {
+		movies = movies[1:]
+	} else if more {
+		movies = movies[:p.limit]
+	}
+
+	// A cursor came from a neighbouring page, which is taken to still exist
+	hasNext, hasPrev := more, p.offset > 0
+	if backwards {
+		hasNext, hasPrev = true, more
+	} else if p.cursor != nil {
+		hasPrev = true
+	}
+
+	meta := ListMeta{Total: total, Limit: p.limit, Offset: p.offset}
+	var links []string
+	link := func(rel string, set map[string]string) {
+		query := u.Query()
+		query.Del("offset")
+		query.Del("cursor")
+		// The limit keeps the linked page paged, even when it is the first
+		query.Set("limit", strconv.Itoa(p.limit))
+		for key, value := range set {
+			query.Set(key, value)
+		}
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", target.String(), rel))
+	}
+
+	if hasNext && len(movies) > 0 {
+		meta.NextCursor = newCursor(movies[len(movies)-1], p.filter, false).encode()
+		if p.cursor != nil {
+			link("next", map[string]string{"cursor": meta.NextCursor})
+		} else {
+			link("next", map[string]string{"offset": strconv.Itoa(p.offset + p.limit)})
+		}
+	}
+
+	if hasPrev && len(movies) > 0 {
+		meta.PrevCursor = newCursor(movies[0], p.filter, true).encode()
+		if p.cursor != nil {
+			link("prev", map[string]string{"cursor": meta.PrevCursor})
+		} else if prev := p.offset - p.limit; prev > 0 {
+			link("prev", map[string]string{"offset": strconv.Itoa(prev)})
+		} else {
+			link("prev", nil)
+		}
+	}
+
+	return movies, meta, strings.Join(links, ", ")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numberedMovies returns movies with the IDs from first to last
+func numberedMovies(first, last int) []Movie {
+	var movies []Movie
+	for id := first; id <= last; id++ {
+		movies = append(movies, Movie{ID: id, MovieID: "m" + strconv.Itoa(id)})
+	}
+	return movies
+}
+
+func movieIDs(movies []Movie) string {
+	ids := make([]string, len(movies))
+	for i, m := range movies {
+		ids[i] = m.MovieID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestPaginate(t *testing.T) {
+	u, _ := url.Parse("/movies/?limit=3")
+	forward := &movieCursor{Values: []interface{}{3}}
+	backward := &movieCursor{Values: []interface{}{7}, Before: true}
+
+	tests := []struct {
+		name string
+		page pageRequest
+		// fetched is what the store returned for page.query()
+		fetched            []Movie
+		want               string
+		wantNext, wantPrev bool
+		wantLinks          string
+	}{
+		{
+			name:      "first page",
+			page:      pageRequest{paged: true, limit: 3},
+			fetched:   numberedMovies(1, 4),
+			want:      "m1,m2,m3",
+			wantNext:  true,
+			wantLinks: `</movies/?limit=3&offset=3>; rel="next"`,
+		},
+		{
+			name:      "last page by offset",
+			page:      pageRequest{paged: true, limit: 3, offset: 3},
+			fetched:   numberedMovies(4, 5),
+			want:      "m4,m5",
+			wantPrev:  true,
+			wantLinks: `</movies/?limit=3>; rel="prev"`,
+		},
+		{
+			name:      "middle page by offset",
+			page:      pageRequest{paged: true, limit: 3, offset: 6},
+			fetched:   numberedMovies(7, 10),
+			want:      "m7,m8,m9",
+			wantNext:  true,
+			wantPrev:  true,
+			wantLinks: `</movies/?limit=3&offset=9>; rel="next", </movies/?limit=3&offset=3>; rel="prev"`,
+		},
+		{
+			name:     "after a cursor",
+			page:     pageRequest{paged: true, limit: 3, cursor: forward},
+			fetched:  numberedMovies(4, 7),
+			want:     "m4,m5,m6",
+			wantNext: true,
+			wantPrev: true,
+		},
+		{
+			// The extra movie paging backwards is the one before the page
+			name:     "before a cursor with more before it",
+			page:     pageRequest{paged: true, limit: 3, cursor: backward},
+			fetched:  numberedMovies(3, 6),
+			want:     "m4,m5,m6",
+			wantNext: true,
+			wantPrev: true,
+		},
+		{
+			name:     "before a cursor at the start",
+			page:     pageRequest{paged: true, limit: 3, cursor: backward},
+			fetched:  numberedMovies(1, 2),
+			want:     "m1,m2",
+			wantNext: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			movies, meta, links := test.page.paginate(test.fetched, 10, u)
+
+			if got := movieIDs(movies); got != test.want {
+				t.Errorf("got movies %s, want %s", got, test.want)
+			}
+			if (meta.NextCursor != "") != test.wantNext || (meta.PrevCursor != "") != test.wantPrev {
+				t.Errorf("got next cursor %q and prev cursor %q, want next %v and prev %v", meta.NextCursor, meta.PrevCursor, test.wantNext, test.wantPrev)
+			}
+			if meta.Total != 10 || meta.Limit != 3 {
+				t.Errorf("got %+v, want a total of 10 and a limit of 3", meta)
+			}
+			if test.wantLinks != "" && links != test.wantLinks {
+				t.Errorf("got links %s, want %s", links, test.wantLinks)
+			}
+		})
+	}
+}
+
+func TestPaginateGivesCursorsOfTheEdges(t *testing.T) {
+	u, _ := url.Parse("/movies/")
+	page := pageRequest{paged: true, limit: 2, offset: 2}
+
+	_, meta, _ := page.paginate(numberedMovies(3, 5), 5, u)
+
+	for cursor, want := range map[string]movieCursor{
+		meta.NextCursor: {Values: []interface{}{4}},
+		meta.PrevCursor: {Values: []interface{}{3}, Before: true},
+	} {
+		c, err := decodeCursor(cursor, MovieQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Before != want.Before || c.Values[0] != want.Values[0] {
+			t.Errorf("got cursor %+v, want %+v", c, want)
+		}
+	}
+}
+
+// Walking forward through every page and back again sees every movie once
+func TestListMoviesByCursor(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Heat", "m2:Alien", "m3:Jaws", "m4:Brazil", "m5:Fargo", "m6:Casino", "m7:Drive")
+
+	var pages []string
+	var cursors []ListMeta
+	target := "/movies/?limit=3&sort=moviename"
+	for target != "" {
+		response := decodeMovies(t, serve(t, router, "GET", target, ""), http.StatusOK)
+		pages = append(pages, movieIDs(response.Data))
+		cursors = append(cursors, *response.Meta)
+
+		target = ""
+		if response.Meta.NextCursor != "" {
+			target = "/movies/?limit=3&sort=moviename&cursor=" + response.Meta.NextCursor
+		}
+	}
+
+	if got := strings.Join(pages, " "); got != "m2,m4,m6 m7,m5,m1 m3" {
+		t.Fatalf("got pages %s, want the movies by name", got)
+	}
+
+	// From the last page, the previous cursors lead back to the first
+	last := cursors[len(cursors)-1]
+	response := decodeMovies(t, serve(t, router, "GET", "/movies/?limit=3&sort=moviename&cursor="+last.PrevCursor, ""), http.StatusOK)
+	if got := movieIDs(response.Data); got != "m7,m5,m1" {
+		t.Fatalf("got %s before the last page, want m7,m5,m1", got)
+	}
+
+	response = decodeMovies(t, serve(t, router, "GET", "/movies/?limit=3&sort=moviename&cursor="+response.Meta.PrevCursor, ""), http.StatusOK)
+	if got := movieIDs(response.Data); got != "m2,m4,m6" || response.Meta.PrevCursor != "" {
+		t.Fatalf("got %s with prev cursor %q, want the first page with none", got, response.Meta.PrevCursor)
+	}
+
+	decodeMovies(t, serve(t, router, "GET", "/movies/?limit=3&sort=-moviename&cursor="+last.PrevCursor, ""), http.StatusBadRequest)
+}
@@ -0,0 +1,26 @@
+package main
+
+// mergePatch applies a JSON Merge Patch (RFC 7396) to target. Both are
+// values decoded by encoding/json, so objects are map[string]interface{}.
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		// Anything but an object replaces the target outright
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+
+	return targetObject
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Roles a person can be credited with on a movie
+var creditRoles = []string{"director", "writer", "actor"}
+
+type Person struct {
+	ID       int    `json:"-"`
+	PersonID string `json:"personid"`
+	Name     string `json:"name"`
+}
+
+type Credit struct {
+	CreditID  int    `json:"creditid"`
+	MovieID   string `json:"movieid"`
+	MovieName string `json:"moviename"`
+	PersonID  string `json:"personid"`
+	Name      string `json:"name"`
+	// director, writer or actor
+	Role string `json:"role"`
+	// Name of the character played, only for actors
+	Character string `json:"character,omitempty"`
+	// Position in the credits, lower comes first and 0 means unbilled
+	BillingOrder int `json:"billing_order,omitempty"`
+}
+
+type PersonResponse struct {
+	Type    string   `json:"type"`
+	Data    []Person `json:"data"`
+	Message string   `json:"message"`
+}
+
+type CreditResponse struct {
+	Type    string   `json:"type"`
+	Data    []Credit `json:"data"`
+	Message string   `json:"message"`
+}
+
+// validate checks a credit before it is added to a movie
+func (c Credit) validate() error {
+	if c.PersonID == "" || c.Role == "" {
+		return errors.New("You are missing personid or role")
+	}
+
+	if !isCreditRole(c.Role) {
+		return fmt.Errorf("role must be one of %s", strings.Join(creditRoles, ", "))
+	}
+
+	if c.Character != "" && c.Role != "actor" {
+		return errors.New("only actors can have a character")
+	}
+
+	if c.BillingOrder < 0 {
+		return errors.New("billing_order cannot be negative")
+	}
+
+	return nil
+}
+
+func isCreditRole(role string) bool {
+	for _, r := range creditRoles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listPeople godoc
+// @Description Get all people from the database
+// @Produce json
+// @Success 200 {object} PersonResponse{type=string,data=[]Person,message=string} "Successfully get all people"
+// @Failure 500 {object} PersonResponse{type=string,message=string} "Fail to get all people"
+// @Router /people/ [get]
+func (s *server) listPeople(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /people")
+
+	people, err := s.store.ListPeople(reader.Context())
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(PersonResponse{Type: "error", Message: "Failed to get all people from the database"})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(PersonResponse{Type: "success", Data: people, Message: "Successfully got all people from DB"})
+}
+
+// getPerson godoc
+// @Description Get a person by their personid
+// @Produce json
+// @Param personid path string true "Person ID"
+// @Success 200 {object} PersonResponse{type=string,data=[]Person,message=string} "Successfully get a person with the specified personid"
+// @Failure 404 {object} PersonResponse{type=string,message=string} "A person with the specified personid could not be found"
+// @Router /people/{personid}/ [get]
+func (s *server) getPerson(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /people/{personid}")
+
+	p, err := s.store.GetPerson(reader.Context(), mux.Vars(reader)["personid"])
+
+	var response = PersonResponse{}
+
+	if errors.Is(err, ErrPersonNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = PersonResponse{Type: "failure", Message: "A person with that personid does not exist."}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = PersonResponse{Type: "error", Message: "Failed to get the person from the database"}
+	} else {
+		response = PersonResponse{Type: "success", Data: []Person{p}, Message: "Successfully got person from DB"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// createPerson godoc
+// @Description Create a new person
+// @Accept json
+// @Produce json
+// @Param person body Person true "Person Data"
+// @Success 201 {object} PersonResponse{type=string,data=[]Person,message=string} "Successfully create a new person"
+// @Failure 400 {object} PersonResponse{type=string,message=string} "personid or name is missing"
+// @Failure 409 {object} PersonResponse{type=string,message=string} "A person with the specified personid already exists"
+// @Router /people/ [post]
+func (s *server) createPerson(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: POST /people")
+
+	var p Person
+	if err := json.NewDecoder(reader.Body).Decode(&p); err != nil || p.PersonID == "" || p.Name == "" {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(PersonResponse{Type: "error", Message: "You are missing personid or name"})
+		return
+	}
+
+	var response = PersonResponse{}
+
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		var err error
+		p, err = store.CreatePerson(reader.Context(), p)
+		return []AuditEntry{auditEntry("create_person", "", nil, p)}, err
+	})
+
+	if errors.Is(err, ErrDuplicate) {
+		writer.WriteHeader(http.StatusConflict)
+		response = PersonResponse{Type: "error", Message: "A person with that personid already exists."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = PersonResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = PersonResponse{Type: "error", Message: "Failed to insert a new person"}
+	} else {
+		writer.WriteHeader(http.StatusCreated)
+		response = PersonResponse{Type: "success", Data: []Person{p}, Message: "The person has been inserted successfully!"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// updatePerson godoc
+// @Description Rename a person
+// @Accept json
+// @Produce json
+// @Param personid path string true "Person ID"
+// @Param person body Person true "Person Data, only the name is used"
+// @Success 200 {object} PersonResponse{type=string,data=[]Person,message=string} "Successfully rename the person"
+// @Failure 400 {object} PersonResponse{type=string,message=string} "name is missing"
+// @Failure 404 {object} PersonResponse{type=string,message=string} "A person with the specified personid could not be found"
+// @Router /people/{personid}/ [put]
+func (s *server) updatePerson(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: PUT /people/{personid}")
+
+	personID := mux.Vars(reader)["personid"]
+
+	var p Person
+	if err := json.NewDecoder(reader.Body).Decode(&p); err != nil || p.Name == "" {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(PersonResponse{Type: "error", Message: "You are missing the person's name"})
+		return
+	}
+
+	// The personid identifies the person and cannot be changed
+	if p.PersonID != "" && p.PersonID != personID {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(PersonResponse{Type: "error", Message: "The personid in the body does not match the URL"})
+		return
+	}
+
+	var response = PersonResponse{}
+
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		// The person is read first for the audit log
+		before, err := store.GetPerson(reader.Context(), personID)
+		if err != nil {
+			return nil, err
+		}
+
+		p, err = store.RenamePerson(reader.Context(), personID, p.Name)
+		return []AuditEntry{auditEntry("rename_person", "", before, p)}, err
+	})
+
+	if errors.Is(err, ErrPersonNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = PersonResponse{Type: "failure", Message: "A person with that personid does not exist."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = PersonResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = PersonResponse{Type: "error", Message: "Failed to update the person"}
+	} else {
+		response = PersonResponse{Type: "success", Data: []Person{p}, Message: "The person has been updated successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// deletePerson godoc
+// @Description Delete a person and all of their credits
+// @Produce json
+// @Param personid path string true "Person ID"
+// @Success 200 {object} PersonResponse{type=string,message=string} "Successfully delete the person"
+// @Failure 404 {object} PersonResponse{type=string,message=string} "A person with the specified personid could not be found"
+// @Router /people/{personid}/ [delete]
+func (s *server) deletePerson(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: DELETE /people/{personid}")
+
+	personID := mux.Vars(reader)["personid"]
+
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		// The person is read first for the audit log
+		before, err := store.GetPerson(reader.Context(), personID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = store.DeletePerson(reader.Context(), personID)
+		return []AuditEntry{auditEntry("delete_person", "", before, nil)}, err
+	})
+
+	var response = PersonResponse{}
+
+	if errors.Is(err, ErrPersonNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = PersonResponse{Type: "failure", Message: "A person with that personid does not exist."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = PersonResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = PersonResponse{Type: "error", Message: "Failed to delete the person"}
+	} else {
+		response = PersonResponse{Type: "success", Message: "The person has been deleted successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// getFilmography godoc
+// @Description Get every credit of a person, ordered by release year
+// @Produce json
+// @Param personid path string true "Person ID"
+// @Success 200 {object} CreditResponse{type=string,data=[]Credit,message=string} "Successfully get the filmography"
+// @Failure 404 {object} CreditResponse{type=string,message=string} "A person with the specified personid could not be found"
+// @Router /people/{personid}/filmography/ [get]
+func (s *server) getFilmography(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /people/{personid}/filmography")
+
+	credits, err := s.store.Filmography(reader.Context(), mux.Vars(reader)["personid"])
+
+	var response = CreditResponse{}
+
+	if errors.Is(err, ErrPersonNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = CreditResponse{Type: "failure", Message: "A person with that personid does not exist."}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = CreditResponse{Type: "error", Message: "Failed to get the filmography from the database"}
+	} else {
+		response = CreditResponse{Type: "success", Data: credits, Message: "Successfully got filmography from DB"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// listCredits godoc
+// @Description Get the credits of a movie in billing order
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Success 200 {object} CreditResponse{type=string,data=[]Credit,message=string} "Successfully get the credits"
+// @Failure 404 {object} CreditResponse{type=string,message=string} "A movie with the specified movieid could not be found"
+// @Router /movies/{movieid}/credits/ [get]
+func (s *server) listCredits(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /movies/{movieid}/credits")
+
+	credits, err := s.store.ListCredits(reader.Context(), mux.Vars(reader)["movieid"])
+
+	var response = CreditResponse{}
+
+	if errors.Is(err, ErrNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = CreditResponse{Type: "failure", Message: "A movie with that movieid does not exist."}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = CreditResponse{Type: "error", Message: "Failed to get the credits from the database"}
+	} else {
+		response = CreditResponse{Type: "success", Data: credits, Message: "Successfully got credits from DB"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// addCredit godoc
+// @Description Credit a person on a movie as director, writer or actor
+// @Accept json
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Param credit body Credit true "Credit Data, personid and role are required"
+// @Success 201 {object} CreditResponse{type=string,data=[]Credit,message=string} "Successfully add the credit"
+// @Failure 400 {object} CreditResponse{type=string,message=string} "A parameter is missing or invalid"
+// @Failure 404 {object} CreditResponse{type=string,message=string} "The movie or the person could not be found"
+// @Failure 409 {object} CreditResponse{type=string,message=string} "The person already has this credit on the movie"
+// @Router /movies/{movieid}/credits/ [post]
+func (s *server) addCredit(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: POST /movies/{movieid}/credits")
+
+	var c Credit
+	if err := json.NewDecoder(reader.Body).Decode(&c); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(CreditResponse{Type: "error", Message: "The request body is not a valid credit"})
+		return
+	}
+
+	if err := c.validate(); err != nil {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(CreditResponse{Type: "error", Message: err.Error()})
+		return
+	}
+
+	var response = CreditResponse{}
+
+	movieID := mux.Vars(reader)["movieid"]
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		var err error
+		c, err = store.AddCredit(reader.Context(), movieID, c)
+		return []AuditEntry{auditEntry("add_credit", movieID, nil, c)}, err
+	})
+
+	if errors.Is(err, ErrNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = CreditResponse{Type: "failure", Message: "A movie with that movieid does not exist."}
+	} else if errors.Is(err, ErrPersonNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = CreditResponse{Type: "failure", Message: "A person with that personid does not exist."}
+	} else if errors.Is(err, ErrDuplicate) {
+		writer.WriteHeader(http.StatusConflict)
+		response = CreditResponse{Type: "error", Message: "That person already has this credit on the movie."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = CreditResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = CreditResponse{Type: "error", Message: "Failed to add the credit"}
+	} else {
+		writer.WriteHeader(http.StatusCreated)
+		response = CreditResponse{Type: "success", Data: []Credit{c}, Message: "The credit has been added successfully!"}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// deleteCredit godoc
+// @Description Remove a credit from a movie
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Param creditid path int true "Credit ID"
+// @Success 200 {object} CreditResponse{type=string,message=string} "Successfully remove the credit"
+// @Failure 404 {object} CreditResponse{type=string,message=string} "The movie has no credit with the specified creditid"
+// @Router /movies/{movieid}/credits/{creditid}/ [delete]
+func (s *server) deleteCredit(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: DELETE /movies/{movieid}/credits/{creditid}")
+	params := mux.Vars(reader)
+
+	var response = CreditResponse{}
+
+	creditID, err := strconv.Atoi(params["creditid"])
+	if err != nil {
+		// A creditid that is not a number cannot match any credit
+		err = ErrCreditNotFound
+	}
+
+	if err == nil {
+		err = s.audited(reader, func(store Store) ([]AuditEntry, error) {
+			// The credit is read first for the audit log
+			credits, err := store.ListCredits(reader.Context(), params["movieid"])
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrCreditNotFound
+			} else if err != nil {
+				return nil, err
+			}
+
+			var before interface{}
+			for _, c := range credits {
+				if c.CreditID == creditID {
+					before = c
+				}
+			}
+
+			err = store.DeleteCredit(reader.Context(), params["movieid"], creditID)
+			return []AuditEntry{auditEntry("delete_credit", params["movieid"], before, nil)}, err
+		})
+	}
+
+	if errors.Is(err, ErrCreditNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = CreditResponse{Type: "failure", Message: "That movie has no credit with that creditid."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = CreditResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = CreditResponse{Type: "error", Message: "Failed to remove the credit"}
+	} else {
+		response = CreditResponse{Type: "success", Message: "The credit has been removed successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
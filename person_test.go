@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// decodeCredits decodes a CreditResponse, failing the test when the status is not the expected one
+func decodeCredits(t *testing.T, recorder *httptest.ResponseRecorder, status int) CreditResponse {
+	t.Helper()
+
+	if recorder.Code != status {
+		t.Fatalf("got status %d, want %d: %s", recorder.Code, status, recorder.Body.String())
+	}
+
+	var response CreditResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("the response is not a CreditResponse: %v: %s", err, recorder.Body.String())
+	}
+	return response
+}
+
+// addPerson creates a person, failing the test when it cannot
+func addPerson(t *testing.T, router http.Handler, personID string, name string) {
+	t.Helper()
+
+	body, _ := json.Marshal(Person{PersonID: personID, Name: name})
+	if recorder := serve(t, router, "POST", "/people/", string(body)); recorder.Code != http.StatusCreated {
+		t.Fatalf("got status %d creating %s, want 201: %s", recorder.Code, personID, recorder.Body.String())
+	}
+}
+
+// creditSummary lists the role and movieid or personid of every credit
+func creditSummary(credits []Credit, byMovie bool) string {
+	var summary string
+	for i, c := range credits {
+		if i > 0 {
+			summary += ","
+		}
+		if byMovie {
+			summary += c.MovieID + ":" + c.Role
+		} else {
+			summary += c.PersonID + ":" + c.Role
+		}
+	}
+	return summary
+}
+
+func TestCreditsAndFilmography(t *testing.T) {
+	router := testRouter(t)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"aliens","moviename":"Aliens","release_year":1986}`), http.StatusCreated)
+	decodeMovies(t, serve(t, router, "POST", "/addmovie/", `{"movieid":"alien","moviename":"Alien","release_year":1979}`), http.StatusCreated)
+	addPerson(t, router, "weaver", "Sigourney Weaver")
+	addPerson(t, router, "scott", "Ridley Scott")
+
+	decodeCredits(t, serve(t, router, "POST", "/movies/alien/credits/", `{"personid":"weaver","role":"actor","character":"Ripley","billing_order":1}`), http.StatusCreated)
+	decodeCredits(t, serve(t, router, "POST", "/movies/alien/credits/", `{"personid":"scott","role":"director"}`), http.StatusCreated)
+	response := decodeCredits(t, serve(t, router, "POST", "/movies/aliens/credits/", `{"personid":"weaver","role":"actor","character":"Ripley","billing_order":1}`), http.StatusCreated)
+	if c := response.Data[0]; c.MovieName != "Aliens" || c.Name != "Sigourney Weaver" {
+		t.Fatalf("got %+v, want the credit with the movie and person names", c)
+	}
+	creditID := response.Data[0].CreditID
+
+	// The same credit twice is a conflict, while another role is not
+	decodeCredits(t, serve(t, router, "POST", "/movies/alien/credits/", `{"personid":"weaver","role":"actor","character":"Ripley"}`), http.StatusConflict)
+	decodeCredits(t, serve(t, router, "POST", "/movies/alien/credits/", `{"personid":"scott","role":"writer"}`), http.StatusCreated)
+
+	// Billed credits come first, unbilled ones after
+	response = decodeCredits(t, serve(t, router, "GET", "/movies/alien/credits/", ""), http.StatusOK)
+	if got := creditSummary(response.Data, false); got != "weaver:actor,scott:director,scott:writer" {
+		t.Fatalf("got credits %s", got)
+	}
+
+	// A filmography is ordered by release year
+	response = decodeCredits(t, serve(t, router, "GET", "/people/weaver/filmography/", ""), http.StatusOK)
+	if got := creditSummary(response.Data, true); got != "alien:actor,aliens:actor" {
+		t.Fatalf("got filmography %s", got)
+	}
+
+	decodeCredits(t, serve(t, router, "DELETE", "/movies/aliens/credits/"+strconv.Itoa(creditID)+"/", ""), http.StatusOK)
+	decodeCredits(t, serve(t, router, "DELETE", "/movies/aliens/credits/"+strconv.Itoa(creditID)+"/", ""), http.StatusNotFound)
+	response = decodeCredits(t, serve(t, router, "GET", "/people/weaver/filmography/", ""), http.StatusOK)
+	if got := creditSummary(response.Data, true); got != "alien:actor" {
+		t.Fatalf("got filmography %s after the credit was removed", got)
+	}
+
+	// Deleting a person removes their credits
+	if recorder := serve(t, router, "DELETE", "/people/scott/", ""); recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d deleting scott, want 200: %s", recorder.Code, recorder.Body.String())
+	}
+	response = decodeCredits(t, serve(t, router, "GET", "/movies/alien/credits/", ""), http.StatusOK)
+	if got := creditSummary(response.Data, false); got != "weaver:actor" {
+		t.Fatalf("got credits %s once scott is deleted", got)
+	}
+}
+
+func TestCreditErrors(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "alien:Alien")
+	addPerson(t, router, "weaver", "Sigourney Weaver")
+
+	if recorder := serve(t, router, "POST", "/people/", `{"personid":"weaver","name":"S. Weaver"}`); recorder.Code != http.StatusConflict {
+		t.Fatalf("got status %d for a duplicate person, want 409", recorder.Code)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		status  int
+		message string
+	}{
+		{"credit on an unknown movie", "POST", "/movies/nope/credits/", `{"personid":"weaver","role":"actor"}`, http.StatusNotFound, "A movie with that movieid does not exist."},
+		{"credit for an unknown person", "POST", "/movies/alien/credits/", `{"personid":"nope","role":"actor"}`, http.StatusNotFound, "A person with that personid does not exist."},
+		{"unknown role", "POST", "/movies/alien/credits/", `{"personid":"weaver","role":"grip"}`, http.StatusBadRequest, "role must be one of director, writer, actor"},
+		{"character for a director", "POST", "/movies/alien/credits/", `{"personid":"weaver","role":"director","character":"Ripley"}`, http.StatusBadRequest, "only actors can have a character"},
+		{"credits of an unknown movie", "GET", "/movies/nope/credits/", "", http.StatusNotFound, "A movie with that movieid does not exist."},
+		{"filmography of an unknown person", "GET", "/people/nope/filmography/", "", http.StatusNotFound, "A person with that personid does not exist."},
+		{"creditid that is not a number", "DELETE", "/movies/alien/credits/first/", "", http.StatusNotFound, "That movie has no credit with that creditid."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := decodeCredits(t, serve(t, router, test.method, test.target, test.body), test.status)
+			if response.Message != test.message {
+				t.Fatalf("got message %q, want %q", response.Message, test.message)
+			}
+		})
+	}
+}
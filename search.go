@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// Hits are wrapped in these tags in SearchHighlights
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// SearchQuery is a full-text search over movie names and synopses
+type SearchQuery struct {
+	// Text holds the words to look for. Every word has to match.
+	Text  string
+	Limit int
+	// Fuzzy matches misspelt words against movie names and movieids instead.
+	// It is served by the handler, from the title index of the server.
+	Fuzzy bool
+}
+
+// SearchResult is a movie found by a search
+type SearchResult struct {
+	Movie
+	// Rank orders the results, higher first. It only compares results of the same search.
+	Rank       float64          `json:"rank"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights hold the text of a result with every hit wrapped in
+// <mark> tags. The text is not HTML-escaped.
+type SearchHighlights struct {
+	MovieName string `json:"moviename"`
+	// Synopsis is cut down to the fragments around the hits when it is long
+	Synopsis string `json:"synopsis,omitempty"`
+}
+
+type SearchResponse struct {
+	Type    string         `json:"type"`
+	Data    []SearchResult `json:"data"`
+	Message string         `json:"message"`
+	Errors  []ParamError   `json:"errors,omitempty"`
+	// Suggestions are the closest movies when a search finds nothing
+	Suggestions []MovieTitle `json:"suggestions,omitempty"`
+}
+
+// parseSearch reads the query string of /movies/search/
+func parseSearch(query url.Values) (SearchQuery, []ParamError) {
+	q := SearchQuery{Text: strings.TrimSpace(query.Get("q")), Limit: defaultSearchLimit}
+	var errs []ParamError
+
+	for _, param := range sortedParams(query) {
+		if param != "q" && param != "limit" && param != "fuzzy" {
+			errs = append(errs, ParamError{Param: param, Message: "Unknown parameter"})
+		}
+	}
+
+	// Punctuation alone holds no word to match, and would match every movie
+	if strings.IndexFunc(q.Text, isWordRune) < 0 {
+		errs = append(errs, ParamError{Param: "q", Message: "q must hold the words to search for"})
+	}
+
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			errs = append(errs, ParamError{Param: "limit", Message: fmt.Sprintf("limit must be a number from 1 to %d", maxSearchLimit)})
+		}
+		q.Limit = n
+	}
+
+	if value := query.Get("fuzzy"); value != "" {
+		fuzzy, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, ParamError{Param: "fuzzy", Message: "fuzzy must be true or false"})
+		}
+		q.Fuzzy = fuzzy
+	}
+
+	return q, errs
+}
+
+// searchMovies godoc
+// @Description Search the names and synopses of movies, best matches first. Every word has to match, in any form, so "alien" also finds "Aliens".
+// @Description Hits are wrapped in <mark> tags in highlights, where long synopses are cut down to the fragments around them.
+// @Produce json
+// @Param q query string true "Words to search for"
+// @Description With fuzzy=true, movie names and movieids close to q are found instead, for misspelt titles.
+// @Description When nothing is found, suggestions holds the closest movies.
+// @Param limit query int false "Most results to return, 20 by default and 100 at most"
+// @Param fuzzy query bool false "Match misspelt names and movieids instead of words"
+// @Success 200 {object} SearchResponse{type=string,data=[]SearchResult,message=string,suggestions=[]MovieTitle} "Successfully search the movies"
+// @Failure 400 {object} SearchResponse{type=string,message=string,errors=[]ParamError} "A query parameter is missing, unknown or invalid"
+// @Failure 500 {object} SearchResponse{type=string,message=string} "Fail to search the movies"
+// @Router /movies/search/ [get]
+func (s *server) searchMovies(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /movies/search")
+
+	q, errs := parseSearch(reader.URL.Query())
+	if len(errs) > 0 {
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(SearchResponse{Type: "error", Message: "Invalid query parameters", Errors: errs})
+		return
+	}
+
+	var results []SearchResult
+	var err error
+	if q.Fuzzy {
+		results, err = s.fuzzySearch(reader.Context(), q)
+	} else {
+		results, err = s.store.Search(reader.Context(), q)
+	}
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(SearchResponse{Type: "error", Message: "Failed to search the movies in the database"})
+		return
+	}
+
+	var response = SearchResponse{Type: "success", Data: results, Message: fmt.Sprintf("Found %d movies", len(results))}
+	if len(results) == 0 {
+		response.Data = []SearchResult{}
+		response.Suggestions = s.suggest(reader.Context(), q.Text)
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// fuzzySearch finds the movies whose name or movieid is close to the text
+// of q, ranked by similarity. The part of the name that matched is
+// highlighted.
+func (s *server) fuzzySearch(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	titles, err := s.titles.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, match := range fuzzyMatches(q.Text, titles) {
+		if len(results) == q.Limit {
+			break
+		}
+
+		m, err := s.store.Get(ctx, match.title.MovieID)
+		if errors.Is(err, ErrNotFound) {
+			// Deleted since the titles were listed
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		r := SearchResult{Movie: m, Rank: match.score}
+		r.Highlights.MovieName = m.MovieName
+		if match.end > 0 && m.MovieName == match.title.MovieName {
+			r.Highlights.MovieName = m.MovieName[:match.start] + highlightStart + m.MovieName[match.start:match.end] + highlightStop + m.MovieName[match.end:]
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestParseSearchNeedsAWord(t *testing.T) {
+	for _, text := range []string{"", "   ", "?!", "-- ..."} {
+		if _, errs := parseSearch(url.Values{"q": {text}}); len(errs) != 1 || errs[0].Param != "q" {
+			t.Errorf("q=%q gave errors %+v, want q refused", text, errs)
+		}
+	}
+
+	q, errs := parseSearch(url.Values{"q": {" alien! "}})
+	if len(errs) != 0 || q.Text != "alien!" || q.Limit != defaultSearchLimit {
+		t.Errorf("got %+v and errors %+v", q, errs)
+	}
+}
+
+func TestSearchMovies(t *testing.T) {
+	router := testRouter(t)
+	for _, body := range []string{
+		`{"movieid":"m1","moviename":"Alien","synopsis":"A crew meets a creature in space"}`,
+		`{"movieid":"m2","moviename":"Aliens","synopsis":"Ripley goes back"}`,
+		`{"movieid":"m3","moviename":"Heat","synopsis":"A thief and a detective"}`,
+	} {
+		decodeMovies(t, serve(t, router, "POST", "/addmovie/", body), http.StatusCreated)
+	}
+
+	search := func(query string) SearchResponse {
+		t.Helper()
+		recorder := serve(t, router, "GET", "/movies/search/?"+query, "")
+		var response SearchResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("got status %d: %s", recorder.Code, recorder.Body.String())
+		}
+		return response
+	}
+
+	response := search("q=alien")
+	if len(response.Data) != 2 || response.Data[0].Highlights.MovieName != "<mark>Alien</mark>" {
+		t.Fatalf("got %+v, want both alien movies", response.Data)
+	}
+
+	// A lone s is not an empty stem matching every word
+	response = search("q=s")
+	if len(response.Data) != 1 || response.Data[0].MovieID != "m1" {
+		t.Fatalf("got %+v, want only the movie with space in it", response.Data)
+	}
+
+	response = search("q=thief+space")
+	if len(response.Data) != 0 {
+		t.Fatalf("got %+v, want every word to have to match", response.Data)
+	}
+
+	if recorder := serve(t, router, "GET", "/movies/search/?q=%3F%21", ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d for punctuation alone, want 400", recorder.Code)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Snapshot describes the movies saved when all movies were deleted
+type Snapshot struct {
+	SnapshotID string    `json:"snapshotid,omitempty"`
+	MovieCount int       `json:"movie_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type SnapshotResponse struct {
+	Type    string     `json:"type"`
+	Data    []Snapshot `json:"data"`
+	Message string     `json:"message"`
+}
+
+// snapshotContents is what a snapshot saves. Genres are kept on the movies
+// and credits refer to movies and people by movieid and personid, since
+// their row IDs do not survive a restore.
+type snapshotContents struct {
+	Movies  []Movie  `json:"movies"`
+	Credits []Credit `json:"credits"`
+}
+
+// listSnapshots godoc
+// @Description Get the snapshots taken when all movies were deleted, newest first
+// @Produce json
+// @Success 200 {object} SnapshotResponse{type=string,data=[]Snapshot,message=string} "Successfully get all snapshots"
+// @Failure 500 {object} SnapshotResponse{type=string,message=string} "Fail to get all snapshots"
+// @Router /snapshots/ [get]
+func (s *server) listSnapshots(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /snapshots")
+
+	snapshots, err := s.store.ListSnapshots(reader.Context())
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(SnapshotResponse{Type: "error", Message: "Failed to get all snapshots from the database"})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(SnapshotResponse{Type: "success", Data: snapshots, Message: "Successfully got all snapshots from DB"})
+}
+
+// restoreSnapshot godoc
+// @Description Undo deleting all movies by putting back the movies of a snapshot, with their genres and credits. The snapshot is used up.
+// @Produce json
+// @Param snapshotid path string true "Snapshot ID"
+// @Success 200 {object} SnapshotResponse{type=string,data=[]Snapshot,message=string} "Successfully restore the snapshot"
+// @Failure 404 {object} SnapshotResponse{type=string,message=string} "A snapshot with the specified snapshotid could not be found"
+// @Failure 409 {object} SnapshotResponse{type=string,message=string} "A movie in the snapshot has been created again since"
+// @Router /snapshots/{snapshotid}/restore/ [post]
+func (s *server) restoreSnapshot(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: POST /snapshots/{snapshotid}/restore")
+
+	var snap Snapshot
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		var err error
+		snap, err = store.RestoreSnapshot(reader.Context(), mux.Vars(reader)["snapshotid"])
+		return []AuditEntry{auditEntry("restore_snapshot", "", nil, snap)}, err
+	})
+
+	var response = SnapshotResponse{}
+
+	if errors.Is(err, ErrSnapshotNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = SnapshotResponse{Type: "failure", Message: "A snapshot with that snapshotid does not exist."}
+	} else if errors.Is(err, ErrDuplicate) {
+		writer.WriteHeader(http.StatusConflict)
+		response = SnapshotResponse{Type: "error", Message: "A movie in the snapshot has been created again since, delete it before restoring."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = SnapshotResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = SnapshotResponse{Type: "error", Message: "Failed to restore the snapshot"}
+	} else {
+		s.titles.invalidate()
+		response = SnapshotResponse{Type: "success", Data: []Snapshot{snap}, Message: "The snapshot has been restored successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// deleteSnapshot godoc
+// @Description Discard a snapshot, after which the movies in it cannot be restored
+// @Produce json
+// @Param snapshotid path string true "Snapshot ID"
+// @Success 200 {object} SnapshotResponse{type=string,message=string} "Successfully delete the snapshot"
+// @Failure 404 {object} SnapshotResponse{type=string,message=string} "A snapshot with the specified snapshotid could not be found"
+// @Router /snapshots/{snapshotid}/ [delete]
+func (s *server) deleteSnapshot(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: DELETE /snapshots/{snapshotid}")
+
+	snapshotID := mux.Vars(reader)["snapshotid"]
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		err := store.DeleteSnapshot(reader.Context(), snapshotID)
+		return []AuditEntry{auditEntry("delete_snapshot", "", map[string]string{"snapshotid": snapshotID}, nil)}, err
+	})
+
+	var response = SnapshotResponse{}
+
+	if errors.Is(err, ErrSnapshotNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = SnapshotResponse{Type: "failure", Message: "A snapshot with that snapshotid does not exist."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = SnapshotResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = SnapshotResponse{Type: "error", Message: "Failed to delete the snapshot"}
+	} else {
+		response = SnapshotResponse{Type: "success", Message: "The snapshot has been deleted successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by a MovieStore when no movie has the requested movieid
+var ErrNotFound = errors.New("movie not found")
+
+// ErrGenreNotFound is returned by a GenreStore when no genre has the requested genreid
+var ErrGenreNotFound = errors.New("genre not found")
+
+// ErrPersonNotFound is returned by a PersonStore when no person has the requested personid
+var ErrPersonNotFound = errors.New("person not found")
+
+// ErrCreditNotFound is returned by a PersonStore when a movie has no credit with the requested creditid
+var ErrCreditNotFound = errors.New("credit not found")
+
+// ErrSnapshotNotFound is returned by a SnapshotStore when no snapshot has the requested snapshotid
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ErrVersionConflict is returned when a write expects a movie version that is no longer current
+var ErrVersionConflict = errors.New("movie version conflict")
+
+// ErrDuplicate is returned when a movie, genre, person or credit with the same identifier already exists
+var ErrDuplicate = errors.New("already exists")
+
+// ErrBatchAborted is given for the movies of an all-or-nothing batch that
+// were not written because another movie of the batch failed
+var ErrBatchAborted = errors.New("batch aborted")
+
+// Store is everything the HTTP handlers need from a storage backend
+type Store interface {
+	MovieStore
+	GenreStore
+	PersonStore
+	TrashStore
+	SnapshotStore
+	AuditStore
+	SearchStore
+
+	// Atomically runs fn with a store whose changes, audit entries included,
+	// are all kept when fn returns nil, and all discarded when it returns an
+	// error, which Atomically returns. fn must make its changes through the
+	// store it is given.
+	Atomically(ctx context.Context, fn func(Store) error) error
+}
+
+// MovieQuery selects a page of movies. The zero value selects every movie.
+type MovieQuery struct {
+	// Conditions must all hold, and when Genre is set the movie must be
+	// classified under the genre with that genreid
+	Conditions []MovieCondition
+	Genre      string
+
+	// Sort orders the movies, by id when empty. See orderBy for ties.
+	Sort []SortKey
+
+	// Fields lists the fields of selectableFields the caller will use, or is
+	// nil for all of them. Stores may leave the other fields empty.
+	Fields []string
+
+	// Limit is the most movies to return, or 0 for no limit
+	Limit  int
+	Offset int
+
+	// After and Before page through movies by keyset. They hold the values
+	// of orderBy for the movie the page starts after or ends just before.
+	// With Before the page holds the last Limit movies before it, still in
+	// sort order.
+	After  []interface{}
+	Before []interface{}
+}
+
+// BatchOptions change how CreateMany writes a batch
+type BatchOptions struct {
+	// Atomic means a failed movie aborts the batch and every other movie
+	// gets ErrBatchAborted
+	Atomic bool
+
+	// DryRun gives the results the batch would have without writing it
+	DryRun bool
+}
+
+// CreateResult is the outcome of creating one movie of a batch: the created
+// movie, or why it was not created
+type CreateResult struct {
+	Movie Movie
+	Err   error
+}
+
+// MovieStore is the storage backend used by the HTTP handlers
+type MovieStore interface {
+	// List returns the movies selected by q in its sort order. Movies returned by
+	// the store always have their genres filled in.
+	List(ctx context.Context, q MovieQuery) ([]Movie, error)
+
+	// Stream calls fn with each movie List would return, in the same order,
+	// without holding them all in memory. It stops at the first error of fn
+	// and returns it.
+	Stream(ctx context.Context, q MovieQuery, fn func(Movie) error) error
+
+	// Count returns how many movies List would return if q was not paged
+	Count(ctx context.Context, q MovieQuery) (int, error)
+
+	// Fingerprint returns an opaque value that changes whenever the result
+	// of List would, without loading every movie
+	Fingerprint(ctx context.Context) (string, error)
+
+	// Get returns the movie with the given movieid, or ErrNotFound
+	Get(ctx context.Context, movieID string) (Movie, error)
+
+	// Create inserts a new movie and returns it with its ID filled in, or
+	// returns ErrDuplicate if the movieid is taken, even by a movie in the
+	// trash. The version, updated_at and deleted_at of m are ignored, as the
+	// store maintains them; the movie never starts in the trash.
+	Create(ctx context.Context, m Movie) (Movie, error)
+
+	// CreateMany inserts movies in one transaction, with a result for each.
+	// A movie whose movieid is taken, by a stored movie or an earlier one of
+	// the batch, gets ErrDuplicate. See BatchOptions for atomic batches and
+	// dry runs, whose movies are returned without an ID.
+	CreateMany(ctx context.Context, movies []Movie, opts BatchOptions) ([]CreateResult, error)
+
+	// Update replaces every field of the movie with the given movieid, apart
+	// from its ID, genres and the fields the store maintains (see Create),
+	// and bumps its version. It returns ErrNotFound,
+	// or ErrVersionConflict when version is not 0 and is not the current one.
+	Update(ctx context.Context, movieID string, m Movie, version int) (Movie, error)
+
+	// Delete moves the movie with the given movieid to the trash, hiding it
+	// from every other method until it is restored. It returns ErrNotFound,
+	// or ErrVersionConflict when version is not 0 and is not the current one.
+	Delete(ctx context.Context, movieID string, version int) error
+
+	// DeleteMany moves every movie selected by q to the trash at once,
+	// ignoring paging, and returns them as they are in the trash, in id order
+	DeleteMany(ctx context.Context, q MovieQuery) ([]Movie, error)
+
+	// CountAll returns how many movies DeleteAll would remove
+	CountAll(ctx context.Context) (int, error)
+
+	// DeleteAll permanently removes every movie from the store, including the
+	// trash. The movies, with their genres and credits, are first saved in a
+	// snapshot which is returned, so that the wipe can be undone.
+	DeleteAll(ctx context.Context) (Snapshot, error)
+
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// TrashStore manages movies that have been deleted but not yet purged
+type TrashStore interface {
+	// ListTrash returns the movies in the trash, most recently deleted first
+	ListTrash(ctx context.Context) ([]Movie, error)
+
+	// GetTrashed returns a movie in the trash, or ErrNotFound when the movie
+	// is not in the trash
+	GetTrashed(ctx context.Context, movieID string) (Movie, error)
+
+	// Restore takes a movie out of the trash and bumps its version, or
+	// returns ErrNotFound when the movie is not in the trash
+	Restore(ctx context.Context, movieID string) (Movie, error)
+
+	// Purge permanently removes a movie in the trash, along with its genres
+	// and credits, or returns ErrNotFound when the movie is not in the trash
+	Purge(ctx context.Context, movieID string) error
+}
+
+// SnapshotStore manages the snapshots taken by DeleteAll
+type SnapshotStore interface {
+	// ListSnapshots returns every snapshot, newest first
+	ListSnapshots(ctx context.Context) ([]Snapshot, error)
+
+	// RestoreSnapshot puts the movies of a snapshot back and removes the
+	// snapshot. Genres and people deleted since are left out. Returns
+	// ErrSnapshotNotFound, or ErrDuplicate when one of the movieids has been
+	// taken again, in which case nothing is restored.
+	RestoreSnapshot(ctx context.Context, snapshotID string) (Snapshot, error)
+
+	// DeleteSnapshot discards a snapshot, or returns ErrSnapshotNotFound
+	DeleteSnapshot(ctx context.Context, snapshotID string) error
+}
+
+// AuditStore keeps the audit log
+type AuditStore interface {
+	// RecordAudit appends entries to the audit log, stamped with the current
+	// time. Entries are recorded with the change they describe, see Atomically.
+	RecordAudit(ctx context.Context, entries ...AuditEntry) error
+
+	// ListAudit returns the entries matching the filter, newest first
+	ListAudit(ctx context.Context, f AuditFilter) ([]AuditEntry, error)
+}
+
+// SearchStore finds movies by the words in their names and synopses, and
+// lists the titles that fuzzy matches are looked for in
+type SearchStore interface {
+	// Search returns the movies matching every word of q.Text, best match
+	// first. Movies in the trash are never found.
+	Search(ctx context.Context, q SearchQuery) ([]SearchResult, error)
+
+	// ListTitles returns the movieid and name of every movie outside the
+	// trash, in id order
+	ListTitles(ctx context.Context) ([]MovieTitle, error)
+}
+
+// GenreStore manages genres and the movies they are attached to
+type GenreStore interface {
+	// ListGenres returns every genre ordered by name
+	ListGenres(ctx context.Context) ([]Genre, error)
+
+	// GetGenre returns the genre with the given genreid, or ErrGenreNotFound
+	GetGenre(ctx context.Context, genreID string) (Genre, error)
+
+	// CreateGenre inserts a new genre, or returns ErrDuplicate if the genreid is taken
+	CreateGenre(ctx context.Context, g Genre) (Genre, error)
+
+	// RenameGenre changes the name of a genre, or returns ErrGenreNotFound
+	RenameGenre(ctx context.Context, genreID string, name string) (Genre, error)
+
+	// DeleteGenre removes a genre and detaches it from every movie, or returns ErrGenreNotFound
+	DeleteGenre(ctx context.Context, genreID string) error
+
+	// Attaching, detaching and renaming genres bump the version of the movies
+	// they change, since the genres are part of a movie.
+
+	// AttachGenre classifies a movie under a genre. Attaching a genre twice
+	// is not an error. Returns ErrNotFound or ErrGenreNotFound when either
+	// side does not exist.
+	AttachGenre(ctx context.Context, movieID string, genreID string) error
+
+	// DetachGenre removes a genre from a movie. Detaching a genre that is
+	// not attached is not an error.
+	DetachGenre(ctx context.Context, movieID string, genreID string) error
+}
+
+// PersonStore manages people and their credits on movies
+type PersonStore interface {
+	// ListPeople returns every person ordered by name
+	ListPeople(ctx context.Context) ([]Person, error)
+
+	// GetPerson returns the person with the given personid, or ErrPersonNotFound
+	GetPerson(ctx context.Context, personID string) (Person, error)
+
+	// CreatePerson inserts a new person, or returns ErrDuplicate if the personid is taken
+	CreatePerson(ctx context.Context, p Person) (Person, error)
+
+	// RenamePerson changes the name of a person, or returns ErrPersonNotFound
+	RenamePerson(ctx context.Context, personID string, name string) (Person, error)
+
+	// DeletePerson removes a person and all of their credits, or returns ErrPersonNotFound
+	DeletePerson(ctx context.Context, personID string) error
+
+	// ListCredits returns the credits of a movie in billing order, or ErrNotFound
+	ListCredits(ctx context.Context, movieID string) ([]Credit, error)
+
+	// Filmography returns the credits of a person ordered by release year,
+	// or ErrPersonNotFound
+	Filmography(ctx context.Context, personID string) ([]Credit, error)
+
+	// AddCredit credits c.PersonID on a movie. Returns ErrNotFound or
+	// ErrPersonNotFound when either side does not exist, and ErrDuplicate when
+	// the person already has the same role and character on the movie.
+	AddCredit(ctx context.Context, movieID string, c Credit) (Credit, error)
+
+	// DeleteCredit removes a credit from a movie, or returns ErrCreditNotFound
+	DeleteCredit(ctx context.Context, movieID string, creditID int) error
+}
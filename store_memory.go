@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStore is a Store that keeps everything in memory. It mirrors the
+// SQL tables: IDs are assigned serially and movieid and genreid must be unique.
+type memoryStore struct {
+	mu sync.RWMutex
+	// txMu lets one Atomically run at a time
+	txMu   sync.Mutex
+	nextID int
+	movies map[string]Movie
+
+	nextGenreID int
+	genres      map[string]Genre
+	// movieGenres maps a movie ID to the set of genre IDs attached to it,
+	// like the movie_genres join table
+	movieGenres map[int]map[int]bool
+
+	nextPersonID int
+	people       map[string]Person
+	nextCreditID int
+	credits      map[int]memoryCredit
+
+	// snapshots and the audit log are kept oldest first
+	snapshots []memorySnapshot
+	audit     []AuditEntry
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		nextID:      1,
+		movies:      make(map[string]Movie),
+		nextGenreID: 1,
+		genres:      make(map[string]Genre),
+		movieGenres: make(map[int]map[int]bool),
+
+		nextPersonID: 1,
+		people:       make(map[string]Person),
+		nextCreditID: 1,
+		credits:      make(map[int]memoryCredit),
+	}
+}
+
+func (s *memoryStore) List(ctx context.Context, q MovieQuery) ([]Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := q.orderBy()
+	var movies []Movie
+	for _, m := range s.selectMovies(q) {
+		values := sortValues(m, keys)
+		if (q.After == nil || compareSortValues(values, q.After, keys) > 0) &&
+			(q.Before == nil || compareSortValues(values, q.Before, keys) < 0) {
+			movies = append(movies, m)
+		}
+	}
+
+	// Walk backwards from the cursor, like the SQL stores
+	if q.Before != nil {
+		reverseMovies(movies)
+	}
+
+	if q.Offset >= len(movies) {
+		return nil, nil
+	}
+	movies = movies[q.Offset:]
+
+	if q.Limit > 0 && len(movies) > q.Limit {
+		movies = movies[:q.Limit]
+	}
+
+	if q.Before != nil {
+		reverseMovies(movies)
+	}
+
+	for i := range movies {
+		movies[i] = s.withGenres(movies[i])
+	}
+
+	return movies, nil
+}
+
+// Stream lists the movies first, as they are already in memory, so that
+// fn is not called while the store is locked
+func (s *memoryStore) Stream(ctx context.Context, q MovieQuery, fn func(Movie) error) error {
+	movies, err := s.List(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range movies {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) Count(ctx context.Context, q MovieQuery) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.selectMovies(q)), nil
+}
+
+// selectMovies returns the movies selected by q, ignoring paging, in the
+// order of q. The caller must hold s.mu.
+func (s *memoryStore) selectMovies(q MovieQuery) []Movie {
+	var movies []Movie
+	for _, m := range s.movies {
+		if m.DeletedAt == nil && s.matches(m, q) {
+			movies = append(movies, m)
+		}
+	}
+
+	keys := q.orderBy()
+	sort.Slice(movies, func(i, j int) bool {
+		return compareSortValues(sortValues(movies[i], keys), sortValues(movies[j], keys), keys) < 0
+	})
+
+	return movies
+}
+
+// matches reports whether m passes the conditions and genre of q
+func (s *memoryStore) matches(m Movie, q MovieQuery) bool {
+	for _, c := range q.Conditions {
+		// A missing value is NULL in the SQL stores, which no condition matches
+		field := movieFields[c.Field]
+		if !field.isSet(m) {
+			return false
+		}
+
+		value := field.value(m)
+		switch c.Op {
+		case opEqual:
+			if value != c.Value {
+				return false
+			}
+		case opPrefix:
+			if !strings.HasPrefix(strings.ToLower(value.(string)), strings.ToLower(c.Value.(string))) {
+				return false
+			}
+		case opMin:
+			if compareValues(value, c.Value) < 0 {
+				return false
+			}
+		case opMax:
+			if compareValues(value, c.Value) > 0 {
+				return false
+			}
+		case opIn:
+			if !contains(c.Value.([]string), value.(string)) {
+				return false
+			}
+		}
+	}
+
+	if q.Genre != "" {
+		g, ok := s.genres[q.Genre]
+		if !ok || !s.movieGenres[m.ID][g.ID] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareSortValues compares two lists of sort values taken with keys
+func compareSortValues(a, b []interface{}, keys []SortKey) int {
+	for i, key := range keys {
+		if c := compareValues(a[i], b[i]); c != 0 {
+			if key.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compares two ints or two strings, returning -1, 0 or 1
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int:
+		b := b.(int)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+func (s *memoryStore) Fingerprint(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count, idSum, versionSum int
+	var lastUpdate time.Time
+	for _, m := range s.movies {
+		if m.DeletedAt != nil {
+			continue
+		}
+		count++
+		idSum += m.ID
+		versionSum += m.Version
+		if m.UpdatedAt.After(lastUpdate) {
+			lastUpdate = m.UpdatedAt
+		}
+	}
+
+	return fmt.Sprintf("%d:%d:%d:%s", count, idSum, versionSum, lastUpdate.Format(time.RFC3339Nano)), nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, movieID string) (Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, ok := s.live(movieID)
+	if !ok {
+		return Movie{}, ErrNotFound
+	}
+
+	return s.withGenres(m), nil
+}
+
+func (s *memoryStore) Create(ctx context.Context, m Movie) (Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.movies[m.MovieID]; ok {
+		return Movie{}, ErrDuplicate
+	}
+
+	m.ID = s.nextID
+	s.nextID++
+	m.Genres = nil
+	m.Version = 1
+	m.UpdatedAt = now()
+	m.DeletedAt = nil
+	s.movies[m.MovieID] = m
+
+	return s.withGenres(m), nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, movieID string, m Movie, version int) (Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.lookupVersion(movieID, version)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	m.ID = existing.ID
+	m.MovieID = movieID
+	m.Genres = nil
+	m.Version = existing.Version + 1
+	m.UpdatedAt = now()
+	m.DeletedAt = nil
+	s.movies[movieID] = m
+
+	return s.withGenres(m), nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, movieID string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.lookupVersion(movieID, version)
+	if err != nil {
+		return err
+	}
+
+	// Genres and credits stay, so that restoring the movie brings them back
+	deletedAt := now()
+	m.DeletedAt = &deletedAt
+	m.UpdatedAt = deletedAt
+	m.Version++
+	s.movies[movieID] = m
+
+	return nil
+}
+
+// live returns the movie with the given movieid unless it is missing or in
+// the trash. The caller must hold s.mu.
+func (s *memoryStore) live(movieID string) (Movie, bool) {
+	m, ok := s.movies[movieID]
+	if !ok || m.DeletedAt != nil {
+		return Movie{}, false
+	}
+
+	return m, true
+}
+
+// lookupVersion returns the movie with the given movieid if version is 0 or
+// its current version. The caller must hold s.mu.
+func (s *memoryStore) lookupVersion(movieID string, version int) (Movie, error) {
+	m, ok := s.live(movieID)
+	if !ok {
+		return Movie{}, ErrNotFound
+	}
+
+	if version != 0 && m.Version != version {
+		return Movie{}, ErrVersionConflict
+	}
+
+	return m, nil
+}
+
+// bumpVersion records that something which is part of the movie with the
+// given ID changed. The caller must hold s.mu.
+func (s *memoryStore) bumpVersion(id int) {
+	for movieID, m := range s.movies {
+		if m.ID == id {
+			m.Version++
+			m.UpdatedAt = now()
+			s.movies[movieID] = m
+		}
+	}
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import "context"
+
+func (s *memoryStore) RecordAudit(ctx context.Context, entries ...AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	createdAt := now()
+	for _, e := range entries {
+		e.AuditID = len(s.audit) + 1
+		e.CreatedAt = createdAt
+		s.audit = append(s.audit, e)
+	}
+
+	return nil
+}
+
+func (s *memoryStore) ListAudit(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := []AuditEntry{}
+	for i := len(s.audit) - 1; i >= 0 && (f.Limit == 0 || len(entries) < f.Limit); i-- {
+		e := s.audit[i]
+		if (f.MovieID != "" && e.MovieID != f.MovieID) ||
+			(f.Actor != "" && e.Actor != f.Actor) ||
+			(!f.Since.IsZero() && e.CreatedAt.Before(f.Since)) ||
+			(!f.Until.IsZero() && !e.CreatedAt.Before(f.Until)) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
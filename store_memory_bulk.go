@@ -0,0 +1,65 @@
+package main
+
+import "context"
+
+func (s *memoryStore) CreateMany(ctx context.Context, movies []Movie, opts BatchOptions) ([]CreateResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]CreateResult, len(movies))
+	taken := make(map[string]bool)
+	failed := false
+	for i, m := range movies {
+		if _, ok := s.movies[m.MovieID]; ok || taken[m.MovieID] {
+			results[i].Err = ErrDuplicate
+			failed = true
+		}
+		taken[m.MovieID] = true
+	}
+
+	if opts.Atomic && failed {
+		abortBatch(results)
+		return results, nil
+	}
+
+	for i, m := range movies {
+		if results[i].Err != nil {
+			continue
+		}
+		if opts.DryRun {
+			m.Genres = []Genre{}
+			m.DeletedAt = nil
+			results[i].Movie = m
+			continue
+		}
+
+		m.ID = s.nextID
+		s.nextID++
+		m.Genres = nil
+		m.Version = 1
+		m.UpdatedAt = now()
+		m.DeletedAt = nil
+		s.movies[m.MovieID] = m
+		results[i].Movie = s.withGenres(m)
+	}
+
+	return results, nil
+}
+
+func (s *memoryStore) DeleteMany(ctx context.Context, q MovieQuery) ([]Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movies := s.selectMovies(q)
+	deletedAt := now()
+	for i, m := range movies {
+		m.DeletedAt = &deletedAt
+		m.UpdatedAt = deletedAt
+		m.Version++
+		s.movies[m.MovieID] = m
+
+		movies[i] = s.withGenres(m)
+	}
+
+	return movies, nil
+}
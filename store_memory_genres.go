@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// withGenres returns a copy of m with its genres filled in. The caller must hold s.mu.
+func (s *memoryStore) withGenres(m Movie) Movie {
+	m.Genres = []Genre{}
+	for _, g := range s.genres {
+		if s.movieGenres[m.ID][g.ID] {
+			m.Genres = append(m.Genres, g)
+		}
+	}
+
+	sortGenres(m.Genres)
+
+	return m
+}
+
+// Order genres by name, then by ID, like the SQL stores
+func sortGenres(genres []Genre) {
+	sort.Slice(genres, func(i, j int) bool {
+		if genres[i].Name != genres[j].Name {
+			return genres[i].Name < genres[j].Name
+		}
+		return genres[i].ID < genres[j].ID
+	})
+}
+
+func (s *memoryStore) ListGenres(ctx context.Context) ([]Genre, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	genres := []Genre{}
+	for _, g := range s.genres {
+		genres = append(genres, g)
+	}
+
+	sortGenres(genres)
+
+	return genres, nil
+}
+
+func (s *memoryStore) GetGenre(ctx context.Context, genreID string) (Genre, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.genres[genreID]
+	if !ok {
+		return Genre{}, ErrGenreNotFound
+	}
+
+	return g, nil
+}
+
+func (s *memoryStore) CreateGenre(ctx context.Context, g Genre) (Genre, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.genres[g.GenreID]; ok {
+		return Genre{}, ErrDuplicate
+	}
+
+	g.ID = s.nextGenreID
+	s.nextGenreID++
+	s.genres[g.GenreID] = g
+
+	return g, nil
+}
+
+func (s *memoryStore) RenameGenre(ctx context.Context, genreID string, name string) (Genre, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.genres[genreID]
+	if !ok {
+		return Genre{}, ErrGenreNotFound
+	}
+
+	g.Name = name
+	s.genres[genreID] = g
+
+	for movieID, attached := range s.movieGenres {
+		if attached[g.ID] {
+			s.bumpVersion(movieID)
+		}
+	}
+
+	return g, nil
+}
+
+func (s *memoryStore) DeleteGenre(ctx context.Context, genreID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.genres[genreID]
+	if !ok {
+		return ErrGenreNotFound
+	}
+
+	delete(s.genres, genreID)
+	for movieID, attached := range s.movieGenres {
+		if attached[g.ID] {
+			delete(attached, g.ID)
+			s.bumpVersion(movieID)
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStore) AttachGenre(ctx context.Context, movieID string, genreID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, g, err := s.lookupMovieGenre(movieID, genreID)
+	if err != nil {
+		return err
+	}
+
+	if s.movieGenres[m.ID][g.ID] {
+		return nil
+	}
+
+	if s.movieGenres[m.ID] == nil {
+		s.movieGenres[m.ID] = make(map[int]bool)
+	}
+	s.movieGenres[m.ID][g.ID] = true
+	s.bumpVersion(m.ID)
+
+	return nil
+}
+
+func (s *memoryStore) DetachGenre(ctx context.Context, movieID string, genreID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, g, err := s.lookupMovieGenre(movieID, genreID)
+	if err != nil {
+		return err
+	}
+
+	if s.movieGenres[m.ID][g.ID] {
+		delete(s.movieGenres[m.ID], g.ID)
+		s.bumpVersion(m.ID)
+	}
+
+	return nil
+}
+
+// The caller must hold s.mu
+func (s *memoryStore) lookupMovieGenre(movieID string, genreID string) (Movie, Genre, error) {
+	m, ok := s.live(movieID)
+	if !ok {
+		return Movie{}, Genre{}, ErrNotFound
+	}
+
+	g, ok := s.genres[genreID]
+	if !ok {
+		return Movie{}, Genre{}, ErrGenreNotFound
+	}
+
+	return m, g, nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// memoryCredit is a row of the credits table, pointing at a movie and a
+// person by their IDs so that renames show up in the credits
+type memoryCredit struct {
+	id           int
+	movieID      int
+	personID     int
+	role         string
+	character    string
+	billingOrder int
+}
+
+// The caller must hold s.mu
+func (s *memoryStore) deleteCredits(match func(c memoryCredit) bool) {
+	for id, c := range s.credits {
+		if match(c) {
+			delete(s.credits, id)
+		}
+	}
+}
+
+// creditsWhere returns the credits matching match, which is also given the
+// credited movie, with the movie and person names filled in. The caller must
+// hold s.mu.
+func (s *memoryStore) creditsWhere(match func(c memoryCredit, m Movie) bool) ([]Credit, []Movie) {
+	moviesByID := make(map[int]Movie, len(s.movies))
+	for _, m := range s.movies {
+		moviesByID[m.ID] = m
+	}
+
+	peopleByID := make(map[int]Person, len(s.people))
+	for _, p := range s.people {
+		peopleByID[p.ID] = p
+	}
+
+	credits := []Credit{}
+	var movies []Movie
+	for _, c := range s.credits {
+		m, p := moviesByID[c.movieID], peopleByID[c.personID]
+		if !match(c, m) {
+			continue
+		}
+
+		credits = append(credits, Credit{
+			CreditID:     c.id,
+			MovieID:      m.MovieID,
+			MovieName:    m.MovieName,
+			PersonID:     p.PersonID,
+			Name:         p.Name,
+			Role:         c.role,
+			Character:    c.character,
+			BillingOrder: c.billingOrder,
+		})
+		movies = append(movies, m)
+	}
+
+	return credits, movies
+}
+
+func (s *memoryStore) ListPeople(ctx context.Context) ([]Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	people := []Person{}
+	for _, p := range s.people {
+		people = append(people, p)
+	}
+
+	sort.Slice(people, func(i, j int) bool {
+		if people[i].Name != people[j].Name {
+			return people[i].Name < people[j].Name
+		}
+		return people[i].ID < people[j].ID
+	})
+
+	return people, nil
+}
+
+func (s *memoryStore) GetPerson(ctx context.Context, personID string) (Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.people[personID]
+	if !ok {
+		return Person{}, ErrPersonNotFound
+	}
+
+	return p, nil
+}
+
+func (s *memoryStore) CreatePerson(ctx context.Context, p Person) (Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.people[p.PersonID]; ok {
+		return Person{}, ErrDuplicate
+	}
+
+	p.ID = s.nextPersonID
+	s.nextPersonID++
+	s.people[p.PersonID] = p
+
+	return p, nil
+}
+
+func (s *memoryStore) RenamePerson(ctx context.Context, personID string, name string) (Person, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.people[personID]
+	if !ok {
+		return Person{}, ErrPersonNotFound
+	}
+
+	p.Name = name
+	s.people[personID] = p
+
+	return p, nil
+}
+
+func (s *memoryStore) DeletePerson(ctx context.Context, personID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.people[personID]
+	if !ok {
+		return ErrPersonNotFound
+	}
+
+	delete(s.people, personID)
+	s.deleteCredits(func(c memoryCredit) bool { return c.personID == p.ID })
+
+	return nil
+}
+
+func (s *memoryStore) ListCredits(ctx context.Context, movieID string) ([]Credit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, ok := s.live(movieID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	credits, _ := s.creditsWhere(func(c memoryCredit, _ Movie) bool { return c.movieID == m.ID })
+
+	// Billed credits come first, then the unbilled ones in the order they were added
+	sort.Slice(credits, func(i, j int) bool {
+		a, b := credits[i], credits[j]
+		if (a.BillingOrder == 0) != (b.BillingOrder == 0) {
+			return a.BillingOrder != 0
+		}
+		if a.BillingOrder != b.BillingOrder {
+			return a.BillingOrder < b.BillingOrder
+		}
+		return a.CreditID < b.CreditID
+	})
+
+	return credits, nil
+}
+
+func (s *memoryStore) Filmography(ctx context.Context, personID string) ([]Credit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.people[personID]
+	if !ok {
+		return nil, ErrPersonNotFound
+	}
+
+	credits, movies := s.creditsWhere(func(c memoryCredit, m Movie) bool {
+		return c.personID == p.ID && m.DeletedAt == nil
+	})
+
+	// Movies without a release year come last
+	sort.Sort(byReleaseYear{credits, movies})
+
+	return credits, nil
+}
+
+// byReleaseYear sorts credits by the release year of their movies
+type byReleaseYear struct {
+	credits []Credit
+	movies  []Movie
+}
+
+func (b byReleaseYear) Len() int { return len(b.credits) }
+
+func (b byReleaseYear) Swap(i, j int) {
+	b.credits[i], b.credits[j] = b.credits[j], b.credits[i]
+	b.movies[i], b.movies[j] = b.movies[j], b.movies[i]
+}
+
+func (b byReleaseYear) Less(i, j int) bool {
+	a, c := b.movies[i], b.movies[j]
+	if (a.ReleaseYear == 0) != (c.ReleaseYear == 0) {
+		return a.ReleaseYear != 0
+	}
+	if a.ReleaseYear != c.ReleaseYear {
+		return a.ReleaseYear < c.ReleaseYear
+	}
+	if a.ID != c.ID {
+		return a.ID < c.ID
+	}
+	return b.credits[i].CreditID < b.credits[j].CreditID
+}
+
+func (s *memoryStore) AddCredit(ctx context.Context, movieID string, c Credit) (Credit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.live(movieID)
+	if !ok {
+		return Credit{}, ErrNotFound
+	}
+
+	p, ok := s.people[c.PersonID]
+	if !ok {
+		return Credit{}, ErrPersonNotFound
+	}
+
+	// Same unique constraint as the credits table
+	for _, existing := range s.credits {
+		if existing.movieID == m.ID && existing.personID == p.ID && existing.role == c.Role && existing.character == c.Character {
+			return Credit{}, ErrDuplicate
+		}
+	}
+
+	row := memoryCredit{
+		id:           s.nextCreditID,
+		movieID:      m.ID,
+		personID:     p.ID,
+		role:         c.Role,
+		character:    c.Character,
+		billingOrder: c.BillingOrder,
+	}
+	s.nextCreditID++
+	s.credits[row.id] = row
+
+	c.CreditID = row.id
+	c.MovieID = m.MovieID
+	c.MovieName = m.MovieName
+	c.Name = p.Name
+
+	return c, nil
+}
+
+func (s *memoryStore) DeleteCredit(ctx context.Context, movieID string, creditID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.credits[creditID]
+	if m, found := s.live(movieID); !ok || !found || c.movieID != m.ID {
+		return ErrCreditNotFound
+	}
+
+	delete(s.credits, creditID)
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Like the synopsis weight of the SQL stores
+const synopsisHitWeight = 0.4
+
+// Synopses longer than this many words are cut down to a fragment
+const snippetWords = 20
+
+// wordSpan is the position of a word in a text, and whether it is a hit
+type wordSpan struct {
+	start, end int
+	hit        bool
+}
+
+// The memory store has no stemmer, so a word matches a search word it
+// starts with, give or take a plural s. The s of a one letter word is kept,
+// as an empty stem would match every word.
+func wordMatches(word, searchWord string) bool {
+	stem := strings.ToLower(searchWord)
+	if len(stem) > 1 {
+		stem = strings.TrimSuffix(stem, "s")
+	}
+	return stem != "" && strings.HasPrefix(strings.ToLower(word), stem)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// findWords splits text into words, marking those matching a search word.
+// It returns how many words matched each search word.
+func findWords(text string, searchWords []string) ([]wordSpan, []int) {
+	var spans []wordSpan
+	counts := make([]int, len(searchWords))
+
+	start := -1
+	for i, r := range text + " " {
+		isWord := isWordRune(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			span := wordSpan{start: start, end: i}
+			for j, searchWord := range searchWords {
+				if wordMatches(text[start:i], searchWord) {
+					span.hit = true
+					counts[j]++
+				}
+			}
+			spans = append(spans, span)
+			start = -1
+		}
+	}
+
+	return spans, counts
+}
+
+// highlight wraps the hits among spans in <mark> tags, keeping the text
+// from byte from to byte to
+func highlight(text string, spans []wordSpan, from, to int) string {
+	var b strings.Builder
+	last := from
+	for _, span := range spans {
+		b.WriteString(text[last:span.start])
+		if span.hit {
+			b.WriteString(highlightStart + text[span.start:span.end] + highlightStop)
+		} else {
+			b.WriteString(text[span.start:span.end])
+		}
+		last = span.end
+	}
+	b.WriteString(text[last:to])
+
+	return b.String()
+}
+
+// snippet highlights a synopsis, cut down to snippetWords words around
+// the first hit when it is longer
+func snippet(text string, spans []wordSpan) string {
+	if len(spans) <= snippetWords {
+		return highlight(text, spans, 0, len(text))
+	}
+
+	first := 0
+	for i, span := range spans {
+		if span.hit {
+			first = i
+			break
+		}
+	}
+
+	start := first - snippetWords/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetWords
+	if end > len(spans) {
+		end, start = len(spans), len(spans)-snippetWords
+	}
+
+	s := highlight(text, spans[start:end], spans[start].start, spans[end-1].end)
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(spans) {
+		s += "..."
+	}
+
+	return s
+}
+
+func (s *memoryStore) Search(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	searchWords := strings.FieldsFunc(q.Text, func(r rune) bool { return !isWordRune(r) })
+
+	var results []SearchResult
+	for _, m := range s.selectMovies(MovieQuery{}) {
+		nameSpans, nameCounts := findWords(m.MovieName, searchWords)
+		synopsisSpans, synopsisCounts := findWords(m.Synopsis, searchWords)
+
+		r := SearchResult{Movie: m}
+		matched := true
+		for i := range searchWords {
+			if nameCounts[i] == 0 && synopsisCounts[i] == 0 {
+				matched = false
+				break
+			}
+			r.Rank += float64(nameCounts[i]) + synopsisHitWeight*float64(synopsisCounts[i])
+		}
+		if !matched {
+			continue
+		}
+
+		r.Highlights.MovieName = highlight(m.MovieName, nameSpans, 0, len(m.MovieName))
+		r.Highlights.Synopsis = snippet(m.Synopsis, synopsisSpans)
+		results = append(results, r)
+	}
+
+	// selectMovies returns the movies in id order, which breaks ties
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	for i := range results {
+		results[i].Movie = s.withGenres(results[i].Movie)
+	}
+
+	return results, nil
+}
+
+func (s *memoryStore) ListTitles(ctx context.Context) ([]MovieTitle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var titles []MovieTitle
+	for _, m := range s.selectMovies(MovieQuery{}) {
+		titles = append(titles, MovieTitle{MovieID: m.MovieID, MovieName: m.MovieName})
+	}
+
+	return titles, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestWordMatches(t *testing.T) {
+	tests := []struct {
+		word, searchWord string
+		want             bool
+	}{
+		{"Alien", "alien", true},
+		{"Aliens", "alien", true},
+		{"Alien", "aliens", true},
+		{"Alien", "ALI", true},
+		{"Predator", "alien", false},
+		{"Space", "s", true},
+		{"Alien", "s", false},
+		{"Alien", "", false},
+	}
+
+	for _, test := range tests {
+		if got := wordMatches(test.word, test.searchWord); got != test.want {
+			t.Errorf("wordMatches(%q, %q) = %v, want %v", test.word, test.searchWord, got, test.want)
+		}
+	}
+}
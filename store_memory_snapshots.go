@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+type memorySnapshot struct {
+	Snapshot
+	contents snapshotContents
+}
+
+func (s *memoryStore) CountAll(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.movies), nil
+}
+
+func (s *memoryStore) DeleteAll(ctx context.Context) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var contents snapshotContents
+	for _, m := range s.movies {
+		contents.Movies = append(contents.Movies, s.withGenres(m))
+	}
+	sort.Slice(contents.Movies, func(i, j int) bool { return contents.Movies[i].ID < contents.Movies[j].ID })
+
+	contents.Credits, _ = s.creditsWhere(func(memoryCredit, Movie) bool { return true })
+	sort.Slice(contents.Credits, func(i, j int) bool { return contents.Credits[i].CreditID < contents.Credits[j].CreditID })
+
+	snap := Snapshot{SnapshotID: randomID(), MovieCount: len(contents.Movies), CreatedAt: now()}
+	s.snapshots = append(s.snapshots, memorySnapshot{Snapshot: snap, contents: contents})
+
+	// Like DELETE FROM movies, this does not reset the serial id
+	s.movies = make(map[string]Movie)
+	s.movieGenres = make(map[int]map[int]bool)
+	s.credits = make(map[int]memoryCredit)
+
+	return snap, nil
+}
+
+func (s *memoryStore) ListSnapshots(ctx context.Context) ([]Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshots := []Snapshot{}
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		snapshots = append(snapshots, s.snapshots[i].Snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func (s *memoryStore) RestoreSnapshot(ctx context.Context, snapshotID string) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.snapshotIndex(snapshotID)
+	if i < 0 {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	snap := s.snapshots[i]
+
+	// Check every movieid first, so that nothing is restored on a conflict
+	for _, m := range snap.contents.Movies {
+		if _, ok := s.movies[m.MovieID]; ok {
+			return Snapshot{}, ErrDuplicate
+		}
+	}
+
+	// The movies get new IDs, so credits are matched to them by movieid
+	for _, m := range snap.contents.Movies {
+		m.ID = s.nextID
+		s.nextID++
+		m.Version++
+		m.UpdatedAt = now()
+
+		for _, g := range m.Genres {
+			if g, ok := s.genres[g.GenreID]; ok {
+				if s.movieGenres[m.ID] == nil {
+					s.movieGenres[m.ID] = make(map[int]bool)
+				}
+				s.movieGenres[m.ID][g.ID] = true
+			}
+		}
+		m.Genres = nil
+
+		s.movies[m.MovieID] = m
+	}
+
+	for _, c := range snap.contents.Credits {
+		p, ok := s.people[c.PersonID]
+		if !ok {
+			continue
+		}
+
+		row := memoryCredit{
+			id:           s.nextCreditID,
+			movieID:      s.movies[c.MovieID].ID,
+			personID:     p.ID,
+			role:         c.Role,
+			character:    c.Character,
+			billingOrder: c.BillingOrder,
+		}
+		s.nextCreditID++
+		s.credits[row.id] = row
+	}
+
+	s.snapshots = append(s.snapshots[:i], s.snapshots[i+1:]...)
+
+	return snap.Snapshot, nil
+}
+
+func (s *memoryStore) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.snapshotIndex(snapshotID)
+	if i < 0 {
+		return ErrSnapshotNotFound
+	}
+
+	s.snapshots = append(s.snapshots[:i], s.snapshots[i+1:]...)
+
+	return nil
+}
+
+// snapshotIndex returns the position of a snapshot in s.snapshots, or -1.
+// The caller must hold s.mu.
+func (s *memoryStore) snapshotIndex(snapshotID string) int {
+	for i, snap := range s.snapshots {
+		if snap.SnapshotID == snapshotID {
+			return i
+		}
+	}
+
+	return -1
+}
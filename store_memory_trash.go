@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+func (s *memoryStore) ListTrash(ctx context.Context) ([]Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var movies []Movie
+	for _, m := range s.movies {
+		if m.DeletedAt != nil {
+			movies = append(movies, s.withGenres(m))
+		}
+	}
+
+	sort.Slice(movies, func(i, j int) bool {
+		if !movies[i].DeletedAt.Equal(*movies[j].DeletedAt) {
+			return movies[i].DeletedAt.After(*movies[j].DeletedAt)
+		}
+		return movies[i].ID < movies[j].ID
+	})
+
+	return movies, nil
+}
+
+func (s *memoryStore) GetTrashed(ctx context.Context, movieID string) (Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, ok := s.movies[movieID]
+	if !ok || m.DeletedAt == nil {
+		return Movie{}, ErrNotFound
+	}
+
+	return s.withGenres(m), nil
+}
+
+func (s *memoryStore) Restore(ctx context.Context, movieID string) (Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.movies[movieID]
+	if !ok || m.DeletedAt == nil {
+		return Movie{}, ErrNotFound
+	}
+
+	m.DeletedAt = nil
+	m.UpdatedAt = now()
+	m.Version++
+	s.movies[movieID] = m
+
+	return s.withGenres(m), nil
+}
+
+func (s *memoryStore) Purge(ctx context.Context, movieID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.movies[movieID]
+	if !ok || m.DeletedAt == nil {
+		return ErrNotFound
+	}
+
+	delete(s.movies, movieID)
+	delete(s.movieGenres, m.ID)
+	s.deleteCredits(func(c memoryCredit) bool { return c.movieID == m.ID })
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import "context"
+
+// memoryState is a copy of everything a memoryStore holds, taken so that a
+// failed Atomically can put it back
+type memoryState struct {
+	nextID      int
+	movies      map[string]Movie
+	nextGenreID int
+	genres      map[string]Genre
+	movieGenres map[int]map[int]bool
+
+	nextPersonID int
+	people       map[string]Person
+	nextCreditID int
+	credits      map[int]memoryCredit
+
+	snapshots []memorySnapshot
+	audit     []AuditEntry
+}
+
+// Atomically runs one fn at a time, and puts back what the store held
+// before fn when it fails. Reads made meanwhile may see the changes of fn
+// before it returns. fn must not call Atomically again.
+func (s *memoryStore) Atomically(ctx context.Context, fn func(Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.RLock()
+	saved := s.save()
+	s.mu.RUnlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.restore(saved)
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// save copies the state of the store. Movies, genres and the like are values,
+// so only the maps and slices holding them are copied. The caller must hold s.mu.
+func (s *memoryStore) save() memoryState {
+	state := memoryState{
+		nextID:       s.nextID,
+		movies:       make(map[string]Movie, len(s.movies)),
+		nextGenreID:  s.nextGenreID,
+		genres:       make(map[string]Genre, len(s.genres)),
+		movieGenres:  make(map[int]map[int]bool, len(s.movieGenres)),
+		nextPersonID: s.nextPersonID,
+		people:       make(map[string]Person, len(s.people)),
+		nextCreditID: s.nextCreditID,
+		credits:      make(map[int]memoryCredit, len(s.credits)),
+		snapshots:    append([]memorySnapshot(nil), s.snapshots...),
+		audit:        append([]AuditEntry(nil), s.audit...),
+	}
+
+	for k, v := range s.movies {
+		state.movies[k] = v
+	}
+	for k, v := range s.genres {
+		state.genres[k] = v
+	}
+	for id, genres := range s.movieGenres {
+		state.movieGenres[id] = make(map[int]bool, len(genres))
+		for k, v := range genres {
+			state.movieGenres[id][k] = v
+		}
+	}
+	for k, v := range s.people {
+		state.people[k] = v
+	}
+	for k, v := range s.credits {
+		state.credits[k] = v
+	}
+
+	return state
+}
+
+// restore puts back a state taken by save. The caller must hold s.mu.
+func (s *memoryStore) restore(state memoryState) {
+	s.nextID = state.nextID
+	s.movies = state.movies
+	s.nextGenreID = state.nextGenreID
+	s.genres = state.genres
+	s.movieGenres = state.movieGenres
+	s.nextPersonID = state.nextPersonID
+	s.people = state.people
+	s.nextCreditID = state.nextCreditID
+	s.credits = state.credits
+	s.snapshots = state.snapshots
+	s.audit = state.audit
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code raised when a unique constraint fails
+const uniqueViolation = "23505"
+
+var postgresDialect = dialect{
+	name:    "postgres",
+	driver:  "postgres",
+	noLimit: "ALL",
+	// EXCLUSIVE also blocks the row locks taken by foreign key checks, so
+	// genres and credits cannot be attached meanwhile, while reads go on
+	lockMovies: "LOCK TABLE movies IN EXCLUSIVE MODE",
+	isUniqueViolation: func(err error) bool {
+		var pqErr *pq.Error
+		return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+	},
+	// websearch_to_tsquery never fails on user input, and stems the words
+	// like the search_vector column
+	searchQuery: "SELECT " + movieColumns + ", ts_rank(search_vector, tsq) AS rank," +
+		" ts_headline('english', moviename, tsq, 'StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true')," +
+		" ts_headline('english', COALESCE(synopsis, ''), tsq, 'StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxFragments=2, MaxWords=20, MinWords=5')" +
+		" FROM movies, websearch_to_tsquery('english', $1) tsq" +
+		" WHERE deleted_at IS NULL AND search_vector @@ tsq" +
+		" ORDER BY rank DESC, id LIMIT $2",
+	searchWords: func(text string) string { return text },
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dialect captures what differs between the SQL databases sqlStore runs on.
+// Queries are written with $n placeholders, which both drivers accept.
+type dialect struct {
+	// name also names the directory holding the dialect's migrations
+	name   string
+	driver string
+
+	// noLimit is a LIMIT that does not limit anything
+	noLimit string
+
+	// lockMovies keeps other transactions from changing the movies, their
+	// genres and credits until the transaction ends. It is empty when
+	// transactions already fail rather than see a change made meanwhile.
+	lockMovies string
+
+	// isUniqueViolation reports whether err was caused by a unique constraint
+	isUniqueViolation func(err error) bool
+
+	// searchQuery selects movieColumns followed by the rank, the highlighted
+	// name and the highlighted synopsis of the movies matching the words in
+	// $1, best first, keeping at most $2 of them
+	searchQuery string
+	// searchWords turns the words of a SearchQuery into $1 of searchQuery
+	searchWords func(text string) string
+}
+
+// sqlStore is a MovieStore backed by the movies table in a SQL database.
+// Inside Atomically tx is set, and every statement runs on it, see conn.
+type sqlStore struct {
+	db      *sql.DB
+	tx      *sql.Tx
+	dialect dialect
+}
+
+func newSQLStore(db *sql.DB, d dialect) *sqlStore {
+	return &sqlStore{db: db, dialect: d}
+}
+
+// movieColumns are the columns read by scanMovie, in order
+const movieColumns = "id, movieid, moviename, release_year, runtime_minutes, synopsis, original_language, rating, version, updated_at, deleted_at"
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Look up the row ID of a movie by its movieid. Movies in the trash are not found.
+func movieRowID(ctx context.Context, q queryer, movieID string) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx, "SELECT id FROM movies WHERE movieid = $1 AND deleted_at IS NULL", movieID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return id, err
+}
+
+// Scan a row of movieColumns into a Movie, mapping NULLs to zero values
+func scanMovie(row scanner) (Movie, error) {
+	var m Movie
+	var releaseYear, runtimeMinutes sql.NullInt64
+	var synopsis, originalLanguage, rating sql.NullString
+	var deletedAt sql.NullTime
+
+	err := row.Scan(&m.ID, &m.MovieID, &m.MovieName, &releaseYear, &runtimeMinutes, &synopsis, &originalLanguage, &rating, &m.Version, &m.UpdatedAt, &deletedAt)
+	if err != nil {
+		return Movie{}, err
+	}
+
+	m.ReleaseYear = int(releaseYear.Int64)
+	m.RuntimeMinutes = int(runtimeMinutes.Int64)
+	m.Synopsis = synopsis.String
+	m.OriginalLanguage = originalLanguage.String
+	m.Rating = rating.String
+	m.UpdatedAt = m.UpdatedAt.UTC()
+	if deletedAt.Valid {
+		deletedAt.Time = deletedAt.Time.UTC()
+		m.DeletedAt = &deletedAt.Time
+	}
+
+	return m, nil
+}
+
+// Optional movie fields are stored as NULL when they are not set
+func nullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// queryMovies runs a query selecting movieColumns and loads the genres of
+// the movies it returns
+func queryMovies(ctx context.Context, q queryer, query string, args ...interface{}) ([]Movie, error) {
+	movies, err := scanMovies(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadGenres(ctx, q, movies); err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
+
+// scanMovies runs a query selecting movieColumns, leaving out the genres
+func scanMovies(ctx context.Context, q queryer, query string, args ...interface{}) ([]Movie, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movies []Movie
+
+	// Iterate through the query results
+	for rows.Next() {
+		m, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
+
+// projectedColumns stand in for the columns of movieColumns that are not
+// wanted, so that the database does not read them while scanMovie still
+// gets a value for every column
+var projectedColumns = map[string]string{
+	"moviename":         "'' AS moviename",
+	"release_year":      "NULL AS release_year",
+	"runtime_minutes":   "NULL AS runtime_minutes",
+	"synopsis":          "NULL AS synopsis",
+	"original_language": "NULL AS original_language",
+	"rating":            "NULL AS rating",
+}
+
+// selectColumns is movieColumns projected to the fields of q. The columns
+// of the sort keys are always read, as cursors are made from them.
+func selectColumns(q MovieQuery) string {
+	if q.Fields == nil {
+		return movieColumns
+	}
+
+	sorted := make(map[string]bool)
+	for _, key := range q.orderBy() {
+		sorted[key.Field] = true
+	}
+
+	columns := strings.Split(movieColumns, ", ")
+	for i, column := range columns {
+		if stand, ok := projectedColumns[column]; ok && !q.wants(column) && !sorted[column] {
+			columns[i] = stand
+		}
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// movieWhere builds the WHERE clause selecting the movies of q, ignoring
+// paging, along with its arguments. Columns come from movieFields only, and
+// conditions compare their raw columns, so that NULLs never match.
+func movieWhere(q MovieQuery) (string, []interface{}) {
+	terms := []string{"deleted_at IS NULL"}
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	for _, c := range q.Conditions {
+		column := movieFields[c.Field].filterColumn
+		switch c.Op {
+		case opPrefix:
+			prefix := strings.ToLower(c.Value.(string))
+			terms = append(terms, fmt.Sprintf("substr(lower(%s), 1, length(CAST(%s AS TEXT))) = %s", column, arg(prefix), arg(prefix)))
+		case opEqual, opMin, opMax:
+			terms = append(terms, column+" "+c.Op+" "+arg(c.Value))
+		case opIn:
+			values := c.Value.([]string)
+			placeholders := make([]string, len(values))
+			for i, v := range values {
+				placeholders[i] = arg(v)
+			}
+			if len(values) == 0 {
+				placeholders = []string{"NULL"}
+			}
+			terms = append(terms, column+" IN ("+strings.Join(placeholders, ", ")+")")
+		}
+	}
+
+	if q.Genre != "" {
+		terms = append(terms, "id IN (SELECT mg.movie_id FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id WHERE g.genreid = "+arg(q.Genre)+")")
+	}
+
+	return strings.Join(terms, " AND "), args
+}
+
+// keysetWhere builds the condition selecting the movies that sort after
+// values in keys, or before them when backwards
+func keysetWhere(keys []SortKey, values []interface{}, backwards bool, arg func(v interface{}) string) string {
+	var alternatives []string
+	for i, key := range keys {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, movieFields[keys[j].Field].column+" = "+arg(values[j]))
+		}
+
+		op := ">"
+		if key.Desc != backwards {
+			op = "<"
+		}
+		terms = append(terms, movieFields[key.Field].column+" "+op+" "+arg(values[i]))
+
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// listQuery builds the query List runs for q. When paging backwards it
+// selects the movies in reverse order.
+func (s *sqlStore) listQuery(q MovieQuery) (string, []interface{}) {
+	where, args := movieWhere(q)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	keys := q.orderBy()
+	backwards := q.Before != nil
+	if q.After != nil {
+		where += " AND " + keysetWhere(keys, q.After, false, arg)
+	}
+	if backwards {
+		// Walk backwards from the cursor, then put the page back in order
+		where += " AND " + keysetWhere(keys, q.Before, true, arg)
+	}
+
+	order := make([]string, len(keys))
+	for i, key := range keys {
+		order[i] = movieFields[key.Field].column
+		if key.Desc != backwards {
+			order[i] += " DESC"
+		}
+	}
+
+	query := "SELECT " + selectColumns(q) + " FROM movies WHERE " + where + " ORDER BY " + strings.Join(order, ", ")
+	if q.Limit > 0 {
+		query += " LIMIT " + arg(q.Limit)
+	}
+	if q.Offset > 0 {
+		// OFFSET must follow a LIMIT on SQLite
+		if q.Limit <= 0 {
+			query += " LIMIT " + s.dialect.noLimit
+		}
+		query += " OFFSET " + arg(q.Offset)
+	}
+
+	return query, args
+}
+
+func (s *sqlStore) List(ctx context.Context, q MovieQuery) ([]Movie, error) {
+	query, args := s.listQuery(q)
+	movies, err := scanMovies(ctx, s.conn(), query, args...)
+	if err == nil && q.wants("genres") {
+		err = loadGenres(ctx, s.conn(), movies)
+	}
+	if q.Before != nil {
+		reverseMovies(movies)
+	}
+
+	return movies, err
+}
+
+// Stream reads movies off the cursor in batches of genreBatchSize, so that
+// their genres are still loaded a batch at a time. A page before a cursor is
+// read in reverse, so it is listed instead, its limit keeping it small.
+func (s *sqlStore) Stream(ctx context.Context, q MovieQuery, fn func(Movie) error) error {
+	if q.Before != nil {
+		movies, err := s.List(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, m := range movies {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	query, args := s.listQuery(q)
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]Movie, 0, genreBatchSize)
+	flush := func() error {
+		if q.wants("genres") {
+			if err := loadGenres(ctx, s.conn(), batch); err != nil {
+				return err
+			}
+		}
+		for _, m := range batch {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		m, err := scanMovie(rows)
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, m)
+		if len(batch) == genreBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+func reverseMovies(movies []Movie) {
+	for i, j := 0, len(movies)-1; i < j; i, j = i+1, j-1 {
+		movies[i], movies[j] = movies[j], movies[i]
+	}
+}
+
+func (s *sqlStore) Count(ctx context.Context, q MovieQuery) (int, error) {
+	where, args := movieWhere(q)
+
+	var count int
+	err := s.conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM movies WHERE "+where, args...).Scan(&count)
+	return count, err
+}
+
+// Fingerprint relies on every change to a movie bumping its version and
+// updated_at, while the count and id sum catch movies being removed.
+func (s *sqlStore) Fingerprint(ctx context.Context) (string, error) {
+	var count, idSum, versionSum int64
+	var lastUpdate sql.NullString
+
+	err := s.conn().QueryRowContext(ctx,
+		"SELECT COUNT(*), COALESCE(SUM(id), 0), COALESCE(SUM(version), 0), MAX(updated_at) FROM movies WHERE deleted_at IS NULL",
+	).Scan(&count, &idSum, &versionSum, &lastUpdate)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d:%d:%d:%s", count, idSum, versionSum, lastUpdate.String), nil
+}
+
+func (s *sqlStore) Get(ctx context.Context, movieID string) (Movie, error) {
+	m, err := scanMovie(s.conn().QueryRowContext(ctx, "SELECT "+movieColumns+" FROM movies WHERE movieid = $1 AND deleted_at IS NULL", movieID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Movie{}, ErrNotFound
+	} else if err != nil {
+		return Movie{}, err
+	}
+
+	movies := []Movie{m}
+	if err := loadGenres(ctx, s.conn(), movies); err != nil {
+		return Movie{}, err
+	}
+
+	return movies[0], nil
+}
+
+func (s *sqlStore) Create(ctx context.Context, m Movie) (Movie, error) {
+	// Execute the query and get the first (and only) row
+	err := s.conn().QueryRowContext(ctx,
+		`INSERT INTO movies(movieid, moviename, release_year, runtime_minutes, synopsis, original_language, rating, version, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, 1, $8) RETURNING id, version, updated_at`,
+		m.MovieID, m.MovieName, nullInt(m.ReleaseYear), nullInt(m.RuntimeMinutes), nullString(m.Synopsis), nullString(m.OriginalLanguage), nullString(m.Rating), now(),
+	).Scan(&m.ID, &m.Version, &m.UpdatedAt)
+
+	if err != nil && s.dialect.isUniqueViolation(err) {
+		return Movie{}, ErrDuplicate
+	}
+
+	// A new movie has no genres yet, and is not in the trash whatever the
+	// caller set
+	m.Genres = []Genre{}
+	m.DeletedAt = nil
+
+	return m, err
+}
+
+func (s *sqlStore) Update(ctx context.Context, movieID string, m Movie, version int) (Movie, error) {
+	err := s.conn().QueryRowContext(ctx,
+		`UPDATE movies SET moviename = $1, release_year = $2, runtime_minutes = $3, synopsis = $4, original_language = $5, rating = $6,
+			version = version + 1, updated_at = $7
+		WHERE movieid = $8 AND deleted_at IS NULL AND ($9 = 0 OR version = $9) RETURNING id, version, updated_at`,
+		m.MovieName, nullInt(m.ReleaseYear), nullInt(m.RuntimeMinutes), nullString(m.Synopsis), nullString(m.OriginalLanguage), nullString(m.Rating),
+		now(), movieID, version,
+	).Scan(&m.ID, &m.Version, &m.UpdatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Movie{}, s.missOrConflict(ctx, movieID)
+	} else if err != nil {
+		return Movie{}, err
+	}
+
+	m.MovieID = movieID
+	m.DeletedAt = nil
+	movies := []Movie{m}
+	if err := loadGenres(ctx, s.conn(), movies); err != nil {
+		return Movie{}, err
+	}
+
+	return movies[0], nil
+}
+
+// Delete moves the movie to the trash. Its genres and credits are kept, so
+// that restoring it brings them back.
+func (s *sqlStore) Delete(ctx context.Context, movieID string, version int) error {
+	result, err := s.conn().ExecContext(ctx,
+		`UPDATE movies SET deleted_at = $1, updated_at = $1, version = version + 1
+		WHERE movieid = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)`,
+		now(), movieID, version)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return s.missOrConflict(ctx, movieID)
+	}
+
+	return nil
+}
+
+// missOrConflict explains why a conditional write on a movie matched no rows
+func (s *sqlStore) missOrConflict(ctx context.Context, movieID string) error {
+	if _, err := movieRowID(ctx, s.conn(), movieID); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// now is the time written to updated_at. It is taken in Go rather than in
+// SQL so that every backend stores the same precision.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// Bump the version of the movies matching a WHERE clause on movies, after
+// something that is part of them changed. The clause numbers its
+// placeholders from $2.
+func bumpVersions(ctx context.Context, tx execer, where string, args ...interface{}) error {
+	args = append([]interface{}{now()}, args...)
+	_, err := tx.ExecContext(ctx, "UPDATE movies SET version = version + 1, updated_at = $1 WHERE "+where, args...)
+	return err
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
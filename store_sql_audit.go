@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// auditBatchSize is how many entries RecordAudit inserts per statement,
+// keeping their seven parameters each under the bind parameter limits
+const auditBatchSize = 500
+
+// RecordAudit inserts the entries of a bulk change a batch at a time
+// rather than one by one
+func (s *sqlStore) RecordAudit(ctx context.Context, entries ...AuditEntry) error {
+	createdAt := now()
+	for start := 0; start < len(entries); start += auditBatchSize {
+		end := start + auditBatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		rows := make([]string, 0, end-start)
+		args := make([]interface{}, 0, 7*(end-start))
+		for _, e := range entries[start:end] {
+			n := len(args)
+			rows = append(rows, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7))
+			args = append(args, e.Actor, e.Action, nullString(e.MovieID), nullJSON(e.Before), nullJSON(e.After), e.RequestID, createdAt)
+		}
+
+		_, err := s.conn().ExecContext(ctx,
+			"INSERT INTO audit_log(actor, action, movieid, before_data, after_data, request_id, created_at) VALUES "+strings.Join(rows, ", "),
+			args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlStore) ListAudit(ctx context.Context, f AuditFilter) ([]AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.MovieID != "" {
+		where("movieid = $%d", f.MovieID)
+	}
+	if f.Actor != "" {
+		where("actor = $%d", f.Actor)
+	}
+	if !f.Since.IsZero() {
+		where("created_at >= $%d", f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		where("created_at < $%d", f.Until.UTC())
+	}
+
+	query := "SELECT id, actor, action, movieid, before_data, after_data, request_id, created_at FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var movieID, before, after sql.NullString
+		if err := rows.Scan(&e.AuditID, &e.Actor, &e.Action, &movieID, &before, &after, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		e.MovieID = movieID.String
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// nullJSON stores JSON as text, which both a JSONB and a TEXT column accept
+func nullJSON(data json.RawMessage) sql.NullString {
+	return sql.NullString{String: string(data), Valid: data != nil}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+func (s *sqlStore) CreateMany(ctx context.Context, movies []Movie, opts BatchOptions) ([]CreateResult, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	results := make([]CreateResult, len(movies))
+	failed := false
+	for i, m := range movies {
+		// A unique violation would abort the whole transaction on Postgres,
+		// so duplicates are skipped and noticed by the missing row instead
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO movies(movieid, moviename, release_year, runtime_minutes, synopsis, original_language, rating, version, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, 1, $8) ON CONFLICT (movieid) DO NOTHING RETURNING id, version, updated_at`,
+			m.MovieID, m.MovieName, nullInt(m.ReleaseYear), nullInt(m.RuntimeMinutes), nullString(m.Synopsis), nullString(m.OriginalLanguage), nullString(m.Rating), now(),
+		).Scan(&m.ID, &m.Version, &m.UpdatedAt)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			results[i].Err = ErrDuplicate
+			failed = true
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		m.Genres = []Genre{}
+		m.DeletedAt = nil
+		results[i].Movie = m
+	}
+
+	if opts.Atomic && failed {
+		abortBatch(results)
+		return results, nil
+	}
+
+	// A dry run inserts like any other batch, so that duplicates are found
+	// the same way, and the deferred rollback discards it
+	if opts.DryRun {
+		for i := range results {
+			results[i].Movie.ID = 0
+		}
+		return results, nil
+	}
+
+	return results, tx.Commit()
+}
+
+// abortBatch marks every movie of a batch that did not fail as not written
+func abortBatch(results []CreateResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = CreateResult{Err: ErrBatchAborted}
+		}
+	}
+}
+
+// DeleteMany selects and trashes the movies in a single statement, so that a
+// movie trashed or created by another request in between cannot be reported
+// without being trashed, or trashed without being reported
+func (s *sqlStore) DeleteMany(ctx context.Context, q MovieQuery) ([]Movie, error) {
+	where, args := movieWhere(q)
+	args = append(args, now())
+	stamp := fmt.Sprintf("$%d", len(args))
+
+	movies, err := queryMovies(ctx, s.conn(),
+		"UPDATE movies SET deleted_at = "+stamp+", updated_at = "+stamp+", version = version + 1 WHERE "+where+" RETURNING "+movieColumns,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING gives the rows in no particular order
+	sort.Slice(movies, func(i, j int) bool { return movies[i].ID < movies[j].ID })
+
+	return movies, nil
+}
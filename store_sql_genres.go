@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Genres are looked up for at most this many movies per query, well below
+// the bind parameter limits of both Postgres and SQLite
+const genreBatchSize = 500
+
+// loadGenres fills in the genres of each movie, ordered by name
+func loadGenres(ctx context.Context, q queryer, movies []Movie) error {
+	byID := make(map[int]*Movie, len(movies))
+	for i := range movies {
+		movies[i].Genres = []Genre{}
+		byID[movies[i].ID] = &movies[i]
+	}
+
+	for start := 0; start < len(movies); start += genreBatchSize {
+		end := start + genreBatchSize
+		if end > len(movies) {
+			end = len(movies)
+		}
+
+		placeholders := make([]string, 0, end-start)
+		args := make([]interface{}, 0, end-start)
+		for i, m := range movies[start:end] {
+			placeholders = append(placeholders, fmt.Sprintf("$%d", i+1))
+			args = append(args, m.ID)
+		}
+
+		rows, err := q.QueryContext(ctx,
+			`SELECT mg.movie_id, g.id, g.genreid, g.name
+			FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id
+			WHERE mg.movie_id IN (`+strings.Join(placeholders, ", ")+`)
+			ORDER BY g.name, g.id`, args...)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var movieRowID int
+			var g Genre
+			if err := rows.Scan(&movieRowID, &g.ID, &g.GenreID, &g.Name); err != nil {
+				rows.Close()
+				return err
+			}
+			byID[movieRowID].Genres = append(byID[movieRowID].Genres, g)
+		}
+
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlStore) ListGenres(ctx context.Context) ([]Genre, error) {
+	rows, err := s.conn().QueryContext(ctx, "SELECT id, genreid, name FROM genres ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []Genre{}
+	for rows.Next() {
+		var g Genre
+		if err := rows.Scan(&g.ID, &g.GenreID, &g.Name); err != nil {
+			return nil, err
+		}
+		genres = append(genres, g)
+	}
+
+	return genres, rows.Err()
+}
+
+func (s *sqlStore) GetGenre(ctx context.Context, genreID string) (Genre, error) {
+	var g Genre
+	err := s.conn().QueryRowContext(ctx, "SELECT id, genreid, name FROM genres WHERE genreid = $1", genreID).
+		Scan(&g.ID, &g.GenreID, &g.Name)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Genre{}, ErrGenreNotFound
+	}
+
+	return g, err
+}
+
+func (s *sqlStore) CreateGenre(ctx context.Context, g Genre) (Genre, error) {
+	err := s.conn().QueryRowContext(ctx, "INSERT INTO genres(genreid, name) VALUES($1, $2) RETURNING id", g.GenreID, g.Name).
+		Scan(&g.ID)
+
+	if err != nil && s.dialect.isUniqueViolation(err) {
+		return Genre{}, ErrDuplicate
+	}
+
+	return g, err
+}
+
+func (s *sqlStore) RenameGenre(ctx context.Context, genreID string, name string) (Genre, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return Genre{}, err
+	}
+	defer tx.Rollback()
+
+	g := Genre{GenreID: genreID, Name: name}
+	err = tx.QueryRowContext(ctx, "UPDATE genres SET name = $1 WHERE genreid = $2 RETURNING id", name, genreID).
+		Scan(&g.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Genre{}, ErrGenreNotFound
+	} else if err != nil {
+		return Genre{}, err
+	}
+
+	if err := bumpVersions(ctx, tx, "id IN (SELECT movie_id FROM movie_genres WHERE genre_id = $2)", g.ID); err != nil {
+		return Genre{}, err
+	}
+
+	return g, tx.Commit()
+}
+
+func (s *sqlStore) DeleteGenre(ctx context.Context, genreID string) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Bump the movies first, while their movie_genres rows still exist
+	err = bumpVersions(ctx, tx,
+		"id IN (SELECT mg.movie_id FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id WHERE g.genreid = $2)", genreID)
+	if err != nil {
+		return err
+	}
+
+	// movie_genres rows go with it through ON DELETE CASCADE
+	result, err := tx.ExecContext(ctx, "DELETE FROM genres WHERE genreid = $1", genreID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrGenreNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) AttachGenre(ctx context.Context, movieID string, genreID string) error {
+	return s.changeGenre(ctx, movieID, genreID,
+		"INSERT INTO movie_genres(movie_id, genre_id) VALUES($1, $2) ON CONFLICT DO NOTHING")
+}
+
+func (s *sqlStore) DetachGenre(ctx context.Context, movieID string, genreID string) error {
+	return s.changeGenre(ctx, movieID, genreID,
+		"DELETE FROM movie_genres WHERE movie_id = $1 AND genre_id = $2")
+}
+
+// Look up the row IDs of a movie and a genre and run query with them
+func (s *sqlStore) changeGenre(ctx context.Context, movieID string, genreID string, query string) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	movieRow, err := movieRowID(ctx, tx, movieID)
+	if err != nil {
+		return err
+	}
+
+	var genreRow int
+	err = tx.QueryRowContext(ctx, "SELECT id FROM genres WHERE genreid = $1", genreID).Scan(&genreRow)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrGenreNotFound
+	} else if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, movieRow, genreRow)
+	if err != nil {
+		return err
+	}
+
+	// Only bump the version when the genres of the movie actually changed
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		if err := bumpVersions(ctx, tx, "id = $2", movieRow); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// creditColumns are the columns read by scanCredit, in order
+const creditColumns = `c.id, m.movieid, m.moviename, p.personid, p.name, c.role, c.character_name, c.billing_order
+	FROM credits c JOIN movies m ON m.id = c.movie_id JOIN people p ON p.id = c.person_id`
+
+func scanCredit(row scanner) (Credit, error) {
+	var c Credit
+	var billingOrder sql.NullInt64
+
+	err := row.Scan(&c.CreditID, &c.MovieID, &c.MovieName, &c.PersonID, &c.Name, &c.Role, &c.Character, &billingOrder)
+	c.BillingOrder = int(billingOrder.Int64)
+
+	return c, err
+}
+
+func queryCredits(ctx context.Context, q queryer, query string, args ...interface{}) ([]Credit, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := []Credit{}
+	for rows.Next() {
+		c, err := scanCredit(rows)
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, c)
+	}
+
+	return credits, rows.Err()
+}
+
+// Look up the row ID of a person by their personid
+func personRowID(ctx context.Context, q queryer, personID string) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx, "SELECT id FROM people WHERE personid = $1", personID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrPersonNotFound
+	}
+	return id, err
+}
+
+func (s *sqlStore) ListPeople(ctx context.Context) ([]Person, error) {
+	rows, err := s.conn().QueryContext(ctx, "SELECT id, personid, name FROM people ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	people := []Person{}
+	for rows.Next() {
+		var p Person
+		if err := rows.Scan(&p.ID, &p.PersonID, &p.Name); err != nil {
+			return nil, err
+		}
+		people = append(people, p)
+	}
+
+	return people, rows.Err()
+}
+
+func (s *sqlStore) GetPerson(ctx context.Context, personID string) (Person, error) {
+	var p Person
+	err := s.conn().QueryRowContext(ctx, "SELECT id, personid, name FROM people WHERE personid = $1", personID).
+		Scan(&p.ID, &p.PersonID, &p.Name)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Person{}, ErrPersonNotFound
+	}
+
+	return p, err
+}
+
+func (s *sqlStore) CreatePerson(ctx context.Context, p Person) (Person, error) {
+	err := s.conn().QueryRowContext(ctx, "INSERT INTO people(personid, name) VALUES($1, $2) RETURNING id", p.PersonID, p.Name).
+		Scan(&p.ID)
+
+	if err != nil && s.dialect.isUniqueViolation(err) {
+		return Person{}, ErrDuplicate
+	}
+
+	return p, err
+}
+
+func (s *sqlStore) RenamePerson(ctx context.Context, personID string, name string) (Person, error) {
+	p := Person{PersonID: personID, Name: name}
+	err := s.conn().QueryRowContext(ctx, "UPDATE people SET name = $1 WHERE personid = $2 RETURNING id", name, personID).
+		Scan(&p.ID)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Person{}, ErrPersonNotFound
+	}
+
+	return p, err
+}
+
+func (s *sqlStore) DeletePerson(ctx context.Context, personID string) error {
+	// Their credits go with them through ON DELETE CASCADE
+	result, err := s.conn().ExecContext(ctx, "DELETE FROM people WHERE personid = $1", personID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPersonNotFound
+	}
+
+	return nil
+}
+
+func (s *sqlStore) ListCredits(ctx context.Context, movieID string) ([]Credit, error) {
+	id, err := movieRowID(ctx, s.conn(), movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Billed credits come first, then the unbilled ones in the order they were added
+	return queryCredits(ctx, s.conn(), "SELECT "+creditColumns+`
+		WHERE c.movie_id = $1
+		ORDER BY CASE WHEN c.billing_order IS NULL THEN 1 ELSE 0 END, c.billing_order, c.id`, id)
+}
+
+func (s *sqlStore) Filmography(ctx context.Context, personID string) ([]Credit, error) {
+	id, err := personRowID(ctx, s.conn(), personID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Movies without a release year come last
+	return queryCredits(ctx, s.conn(), "SELECT "+creditColumns+`
+		WHERE c.person_id = $1 AND m.deleted_at IS NULL
+		ORDER BY CASE WHEN m.release_year IS NULL THEN 1 ELSE 0 END, m.release_year, m.id, c.id`, id)
+}
+
+func (s *sqlStore) AddCredit(ctx context.Context, movieID string, c Credit) (Credit, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return Credit{}, err
+	}
+	defer tx.Rollback()
+
+	movieRow, err := movieRowID(ctx, tx, movieID)
+	if err != nil {
+		return Credit{}, err
+	}
+
+	personRow, err := personRowID(ctx, tx, c.PersonID)
+	if err != nil {
+		return Credit{}, err
+	}
+
+	var creditID int
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO credits(movie_id, person_id, role, character_name, billing_order) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		movieRow, personRow, c.Role, c.Character, nullInt(c.BillingOrder),
+	).Scan(&creditID)
+
+	if err != nil && s.dialect.isUniqueViolation(err) {
+		return Credit{}, ErrDuplicate
+	} else if err != nil {
+		return Credit{}, err
+	}
+
+	// Read the credit back to fill in the movie and person names
+	c, err = scanCredit(tx.QueryRowContext(ctx, "SELECT "+creditColumns+" WHERE c.id = $1", creditID))
+	if err != nil {
+		return Credit{}, err
+	}
+
+	return c, tx.Commit()
+}
+
+func (s *sqlStore) DeleteCredit(ctx context.Context, movieID string, creditID int) error {
+	result, err := s.conn().ExecContext(ctx,
+		"DELETE FROM credits WHERE id = $1 AND movie_id = (SELECT id FROM movies WHERE movieid = $2 AND deleted_at IS NULL)",
+		creditID, movieID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrCreditNotFound
+	}
+
+	return nil
+}
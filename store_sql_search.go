@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+)
+
+// extraScanner scans movieColumns along with the columns that follow them
+type extraScanner struct {
+	row   scanner
+	extra []interface{}
+}
+
+func (e extraScanner) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.extra...)...)
+}
+
+func (s *sqlStore) Search(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	rows, err := s.conn().QueryContext(ctx, s.dialect.searchQuery, s.dialect.searchWords(q.Text), q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		var synopsis sql.NullString
+
+		r.Movie, err = scanMovie(extraScanner{rows, []interface{}{&r.Rank, &r.Highlights.MovieName, &synopsis}})
+		if err != nil {
+			return nil, err
+		}
+		r.Highlights.Synopsis = synopsis.String
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	movies := make([]Movie, len(results))
+	for i, r := range results {
+		movies[i] = r.Movie
+	}
+	if err := loadGenres(ctx, s.conn(), movies); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Movie = movies[i]
+	}
+
+	return results, nil
+}
+
+func (s *sqlStore) ListTitles(ctx context.Context) ([]MovieTitle, error) {
+	rows, err := s.conn().QueryContext(ctx, "SELECT movieid, moviename FROM movies WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var titles []MovieTitle
+	for rows.Next() {
+		var t MovieTitle
+		if err := rows.Scan(&t.MovieID, &t.MovieName); err != nil {
+			return nil, err
+		}
+		titles = append(titles, t)
+	}
+
+	return titles, rows.Err()
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+func (s *sqlStore) CountAll(ctx context.Context) (int, error) {
+	var count int
+	err := s.conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM movies").Scan(&count)
+	return count, err
+}
+
+// DeleteAll locks the movies before reading the snapshot, so that the
+// delete removes the same movies. Otherwise a movie, genre or credit
+// committed in between would be deleted without being saved.
+func (s *sqlStore) DeleteAll(ctx context.Context) (Snapshot, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer tx.Rollback()
+
+	if s.dialect.lockMovies != "" {
+		if _, err := tx.ExecContext(ctx, s.dialect.lockMovies); err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	var contents snapshotContents
+
+	contents.Movies, err = queryMovies(ctx, tx, "SELECT "+movieColumns+" FROM movies ORDER BY id")
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	contents.Credits, err = queryCredits(ctx, tx, "SELECT "+creditColumns+" ORDER BY c.id")
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snap := Snapshot{SnapshotID: randomID(), MovieCount: len(contents.Movies), CreatedAt: now()}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO snapshots(snapshotid, movie_count, created_at, data) VALUES($1, $2, $3, $4)",
+		snap.SnapshotID, snap.MovieCount, snap.CreatedAt, string(data))
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	// Genres and credits of the movies go with them through the foreign keys
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movies"); err != nil {
+		return Snapshot{}, err
+	}
+
+	return snap, tx.Commit()
+}
+
+func (s *sqlStore) ListSnapshots(ctx context.Context) ([]Snapshot, error) {
+	rows, err := s.conn().QueryContext(ctx, "SELECT snapshotid, movie_count, created_at FROM snapshots ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []Snapshot{}
+	for rows.Next() {
+		var snap Snapshot
+		if err := rows.Scan(&snap.SnapshotID, &snap.MovieCount, &snap.CreatedAt); err != nil {
+			return nil, err
+		}
+		snap.CreatedAt = snap.CreatedAt.UTC()
+		snapshots = append(snapshots, snap)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func (s *sqlStore) RestoreSnapshot(ctx context.Context, snapshotID string) (Snapshot, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer tx.Rollback()
+
+	snap := Snapshot{SnapshotID: snapshotID}
+	var data string
+	err = tx.QueryRowContext(ctx, "SELECT movie_count, created_at, data FROM snapshots WHERE snapshotid = $1", snapshotID).
+		Scan(&snap.MovieCount, &snap.CreatedAt, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Snapshot{}, ErrSnapshotNotFound
+	} else if err != nil {
+		return Snapshot{}, err
+	}
+	snap.CreatedAt = snap.CreatedAt.UTC()
+
+	var contents snapshotContents
+	if err := json.Unmarshal([]byte(data), &contents); err != nil {
+		return Snapshot{}, err
+	}
+
+	// The movies get new row IDs, so credits are matched to them by movieid
+	rowIDs := make(map[string]int, len(contents.Movies))
+	for _, m := range contents.Movies {
+		var id int
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO movies(movieid, moviename, release_year, runtime_minutes, synopsis, original_language, rating, version, updated_at, deleted_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+			m.MovieID, m.MovieName, nullInt(m.ReleaseYear), nullInt(m.RuntimeMinutes), nullString(m.Synopsis), nullString(m.OriginalLanguage), nullString(m.Rating),
+			m.Version+1, now(), m.DeletedAt,
+		).Scan(&id)
+		if err != nil && s.dialect.isUniqueViolation(err) {
+			return Snapshot{}, ErrDuplicate
+		} else if err != nil {
+			return Snapshot{}, err
+		}
+		rowIDs[m.MovieID] = id
+
+		for _, g := range m.Genres {
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO movie_genres(movie_id, genre_id) SELECT $1, id FROM genres WHERE genreid = $2", id, g.GenreID)
+			if err != nil {
+				return Snapshot{}, err
+			}
+		}
+	}
+
+	for _, c := range contents.Credits {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO credits(movie_id, person_id, role, character_name, billing_order)
+			SELECT $1, id, $3, $4, $5 FROM people WHERE personid = $2`,
+			rowIDs[c.MovieID], c.PersonID, c.Role, c.Character, nullInt(c.BillingOrder))
+		if err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM snapshots WHERE snapshotid = $1", snapshotID); err != nil {
+		return Snapshot{}, err
+	}
+
+	return snap, tx.Commit()
+}
+
+func (s *sqlStore) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	result, err := s.conn().ExecContext(ctx, "DELETE FROM snapshots WHERE snapshotid = $1", snapshotID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrSnapshotNotFound
+	}
+
+	return nil
+}
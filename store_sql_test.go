@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestKeysetWhere(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      []SortKey
+		values    []interface{}
+		backwards bool
+		want      string
+	}{
+		{
+			name:   "id alone",
+			keys:   []SortKey{{Field: "id"}},
+			values: []interface{}{4},
+			want:   "((id > $1))",
+		},
+		{
+			name:   "ties broken by id",
+			keys:   []SortKey{{Field: "moviename"}, {Field: "id"}},
+			values: []interface{}{"Heat", 4},
+			want:   "((moviename > $1) OR (moviename = $2 AND id > $3))",
+		},
+		{
+			name:      "backwards",
+			keys:      []SortKey{{Field: "moviename"}, {Field: "id"}},
+			values:    []interface{}{"Heat", 4},
+			backwards: true,
+			want:      "((moviename < $1) OR (moviename = $2 AND id < $3))",
+		},
+		{
+			name:   "descending",
+			keys:   []SortKey{{Field: "release_year", Desc: true}, {Field: "id"}},
+			values: []interface{}{1979, 4},
+			want:   "((COALESCE(release_year, 0) < $1) OR (COALESCE(release_year, 0) = $2 AND id > $3))",
+		},
+		{
+			name:      "descending backwards",
+			keys:      []SortKey{{Field: "release_year", Desc: true}, {Field: "id"}},
+			values:    []interface{}{1979, 4},
+			backwards: true,
+			want:      "((COALESCE(release_year, 0) > $1) OR (COALESCE(release_year, 0) = $2 AND id < $3))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var args []interface{}
+			arg := func(v interface{}) string {
+				args = append(args, v)
+				return fmt.Sprintf("$%d", len(args))
+			}
+
+			if got := keysetWhere(test.keys, test.values, test.backwards, arg); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+
+			// Every key is compared against its own value, after the
+			// equalities on the keys before it
+			var want []interface{}
+			for i := range test.keys {
+				want = append(want, test.values[:i+1]...)
+			}
+			if !reflect.DeepEqual(args, want) {
+				t.Errorf("got arguments %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestMovieWhere(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []MovieCondition
+		want       string
+	}{
+		{
+			name: "no conditions",
+			want: "deleted_at IS NULL",
+		},
+		{
+			name:       "optional fields are compared raw",
+			conditions: []MovieCondition{{Field: "release_year", Op: opMax, Value: 1990}, {Field: "rating", Op: opEqual, Value: "R"}},
+			want:       "deleted_at IS NULL AND release_year <= $1 AND rating = $2",
+		},
+		{
+			name:       "prefix",
+			conditions: []MovieCondition{{Field: "moviename", Op: opPrefix, Value: "Al"}},
+			want:       "deleted_at IS NULL AND substr(lower(moviename), 1, length(CAST($1 AS TEXT))) = $2",
+		},
+		{
+			name:       "in nothing",
+			conditions: []MovieCondition{{Field: "movieid", Op: opIn, Value: []string{}}},
+			want:       "deleted_at IS NULL AND movieid IN (NULL)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, _ := movieWhere(MovieQuery{Conditions: test.conditions}); got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestListQueryWalksBackwardsFromACursor(t *testing.T) {
+	store := &sqlStore{dialect: dialect{noLimit: "-1"}}
+	q := MovieQuery{Sort: []SortKey{{Field: "moviename"}}, Limit: 3, Before: []interface{}{"Heat", 4}}
+
+	query, args := store.listQuery(q)
+	want := "SELECT " + movieColumns + " FROM movies WHERE deleted_at IS NULL AND ((moviename < $1) OR (moviename = $2 AND id < $3)) ORDER BY moviename DESC, id DESC LIMIT $4"
+	if query != want {
+		t.Errorf("got %s\nwant %s", query, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"Heat", "Heat", 4, 3}) {
+		t.Errorf("got arguments %v", args)
+	}
+}
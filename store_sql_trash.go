@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+func (s *sqlStore) ListTrash(ctx context.Context) ([]Movie, error) {
+	return queryMovies(ctx, s.conn(), "SELECT "+movieColumns+" FROM movies WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, id")
+}
+
+func (s *sqlStore) GetTrashed(ctx context.Context, movieID string) (Movie, error) {
+	movies, err := queryMovies(ctx, s.conn(), "SELECT "+movieColumns+" FROM movies WHERE movieid = $1 AND deleted_at IS NOT NULL", movieID)
+	if err != nil {
+		return Movie{}, err
+	}
+	if len(movies) == 0 {
+		return Movie{}, ErrNotFound
+	}
+
+	return movies[0], nil
+}
+
+func (s *sqlStore) Restore(ctx context.Context, movieID string) (Movie, error) {
+	m, err := scanMovie(s.conn().QueryRowContext(ctx,
+		`UPDATE movies SET deleted_at = NULL, updated_at = $1, version = version + 1
+		WHERE movieid = $2 AND deleted_at IS NOT NULL RETURNING `+movieColumns,
+		now(), movieID))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return Movie{}, ErrNotFound
+	} else if err != nil {
+		return Movie{}, err
+	}
+
+	movies := []Movie{m}
+	if err := loadGenres(ctx, s.conn(), movies); err != nil {
+		return Movie{}, err
+	}
+
+	return movies[0], nil
+}
+
+// Purge relies on the foreign keys of movie_genres and credits cascading
+func (s *sqlStore) Purge(ctx context.Context, movieID string) error {
+	result, err := s.conn().ExecContext(ctx, "DELETE FROM movies WHERE movieid = $1 AND deleted_at IS NOT NULL", movieID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
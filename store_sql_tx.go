@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+)
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	queryer
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// txn is a transaction begun by a store method, see begin
+type txn interface {
+	execer
+	Commit() error
+	Rollback() error
+}
+
+// conn is what the store runs its statements on: the transaction of
+// Atomically when there is one, or the database
+func (s *sqlStore) conn() execer {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// begin starts a transaction for a method that makes several changes. Inside
+// Atomically it sets a savepoint instead, so that the method still keeps or
+// discards its own changes while they are committed with the rest.
+func (s *sqlStore) begin(ctx context.Context) (txn, error) {
+	if s.tx == nil {
+		return s.db.BeginTx(ctx, nil)
+	}
+
+	if _, err := s.tx.ExecContext(ctx, "SAVEPOINT store_method"); err != nil {
+		return nil, err
+	}
+	return &savepoint{execer: s.tx, ctx: ctx}, nil
+}
+
+// savepoint is a txn nested in the transaction of Atomically. Store methods
+// do not nest their own transactions, so one savepoint name is enough.
+type savepoint struct {
+	execer
+	ctx  context.Context
+	done bool
+}
+
+func (sp *savepoint) Commit() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+
+	_, err := sp.ExecContext(sp.ctx, "RELEASE SAVEPOINT store_method")
+	return err
+}
+
+// Rollback discards the changes made since the savepoint, which also clears
+// an error that aborted the transaction on Postgres
+func (sp *savepoint) Rollback() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+
+	if _, err := sp.ExecContext(sp.ctx, "ROLLBACK TO SAVEPOINT store_method"); err != nil {
+		return err
+	}
+	_, err := sp.ExecContext(sp.ctx, "RELEASE SAVEPOINT store_method")
+	return err
+}
+
+// Atomically runs fn with a store bound to a transaction, committed when fn
+// returns nil. Inside another Atomically, fn joins its transaction.
+func (s *sqlStore) Atomically(ctx context.Context, fn func(Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&sqlStore{db: s.db, tx: tx, dialect: s.dialect}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
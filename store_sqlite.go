@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var sqliteDialect = dialect{
+	name:    "sqlite",
+	driver:  "sqlite",
+	noLimit: "-1",
+	// A write transaction that read the database fails with SQLITE_BUSY
+	// when another connection has written to it since, so nothing is lost
+	lockMovies: "",
+	isUniqueViolation: func(err error) bool {
+		var sqliteErr *sqlite.Error
+		if !errors.As(err, &sqliteErr) {
+			return false
+		}
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	},
+	// bm25 is lower for better matches, and weighs names like the
+	// setweight of the Postgres search_vector
+	searchQuery: "SELECT " + movieColumns + ", hits.rank, hits.name_highlight, hits.synopsis_snippet" +
+		" FROM movies JOIN (SELECT rowid, -bm25(movies_fts, 1.0, 0.4) AS rank," +
+		" highlight(movies_fts, 0, '" + highlightStart + "', '" + highlightStop + "') AS name_highlight," +
+		" snippet(movies_fts, 1, '" + highlightStart + "', '" + highlightStop + "', '...', 20) AS synopsis_snippet" +
+		" FROM movies_fts WHERE movies_fts MATCH $1) hits ON hits.rowid = movies.id" +
+		" WHERE deleted_at IS NULL" +
+		" ORDER BY hits.rank DESC, id LIMIT $2",
+	searchWords: ftsPhrases,
+}
+
+// ftsPhrases quotes every word of text, so that FTS5 reads them as plain
+// words to match rather than as its query syntax. Words are split like the
+// memory store splits them, so punctuation never makes an empty phrase.
+func ftsPhrases(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool { return !isWordRune(r) })
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// sqliteDSN turns a database file path into a data source name that enables
+// foreign keys and waits for locks instead of failing straight away
+func sqliteDSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// testSQLiteStore opens a migrated SQLite store in a temporary file, closed
+// when the test ends
+func testSQLiteStore(t *testing.T) *sqlStore {
+	t.Helper()
+
+	db, err := sql.Open(sqliteDialect.driver, sqliteDSN(filepath.Join(t.TempDir(), "movies.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mg, err := newMigrator(db, sqliteDialect.name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mg.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return newSQLStore(db, sqliteDialect)
+}
+
+func TestFTSPhrases(t *testing.T) {
+	tests := map[string]string{
+		"alien":            `"alien"`,
+		"alien NOT space":  `"alien" "NOT" "space"`,
+		`"alien" - space*`: `"alien" "space"`,
+		"--":               "",
+	}
+
+	for text, want := range tests {
+		if got := ftsPhrases(text); got != want {
+			t.Errorf("ftsPhrases(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// forEachStore runs a test of the Store contract against every backend that
+// runs without a server, each time on a fresh store
+func forEachStore(t *testing.T, test func(t *testing.T, store Store)) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return newMemoryStore() },
+		"sqlite": func(t *testing.T) Store { return testSQLiteStore(t) },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			test(t, newStore(t))
+		})
+	}
+}
+
+// createMovies creates movies in the store, failing the test when it cannot
+func createMovies(t *testing.T, store Store, movies ...Movie) {
+	t.Helper()
+
+	for _, m := range movies {
+		if _, err := store.Create(context.Background(), m); err != nil {
+			t.Fatalf("creating %s: %v", m.MovieID, err)
+		}
+	}
+}
+
+// listIDs lists the movies selected by q and joins their movieids with commas
+func listIDs(t *testing.T, store Store, q MovieQuery) string {
+	t.Helper()
+
+	movies, err := store.List(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return movieIDs(movies)
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open(sqliteDialect.driver, sqliteDSN(filepath.Join(t.TempDir(), "movies.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mg, err := newMigrator(db, sqliteDialect.name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSchema(ctx, mg); err == nil {
+		t.Fatal("an empty database passed the schema check")
+	}
+
+	applied, err := mg.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(mg.migrations) || applied[len(applied)-1].version != mg.Latest() {
+		t.Fatalf("applied %d migrations, want all %d", len(applied), len(mg.migrations))
+	}
+	if err := checkSchema(ctx, mg); err != nil {
+		t.Fatal(err)
+	}
+	if applied, err := mg.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("applied %d migrations again, err %v, want none", len(applied), err)
+	}
+
+	// Every migration rolls back, and the schema can be built again after
+	for range mg.migrations {
+		if _, ok, err := mg.Down(ctx); err != nil || !ok {
+			t.Fatalf("rolling back: %v", err)
+		}
+	}
+	if version, err := mg.Version(ctx); err != nil || version != 0 {
+		t.Fatalf("got version %d, err %v, want 0 once every migration is rolled back", version, err)
+	}
+	if _, ok, err := mg.Down(ctx); err != nil || ok {
+		t.Fatalf("rolled back past the first migration, err %v", err)
+	}
+	if _, err := mg.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newSQLStore(db, sqliteDialect)
+	createMovies(t, store, Movie{MovieID: "m1", MovieName: "Alien"})
+}
+
+func TestStoreCRUD(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+
+		created, err := store.Create(ctx, Movie{MovieID: "m1", MovieName: "Alien", ReleaseYear: 1979, Rating: "R", Version: 9})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.ID == 0 || created.Version != 1 || created.UpdatedAt.IsZero() || created.DeletedAt != nil {
+			t.Fatalf("got %+v, want an ID, version 1 and an update time", created)
+		}
+		if _, err := store.Create(ctx, Movie{MovieID: "m1", MovieName: "Aliens"}); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("got %v creating m1 again, want ErrDuplicate", err)
+		}
+
+		m, err := store.Get(ctx, "m1")
+		if err != nil || m.MovieName != "Alien" || m.ReleaseYear != 1979 || m.Rating != "R" || m.Genres == nil {
+			t.Fatalf("got %+v, %v, want Alien with its fields and no genres", m, err)
+		}
+		if _, err := store.Get(ctx, "nope"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+
+		fingerprint, err := store.Fingerprint(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The rating is cleared, as an update replaces every field
+		updated, err := store.Update(ctx, "m1", Movie{MovieID: "m1", MovieName: "Aliens", ReleaseYear: 1986}, 1)
+		if err != nil || updated.Version != 2 || updated.ID != created.ID || updated.Rating != "" {
+			t.Fatalf("got %+v, %v, want Aliens at version 2 without a rating", updated, err)
+		}
+		if after, err := store.Fingerprint(ctx); err != nil || after == fingerprint {
+			t.Fatalf("the fingerprint did not change with the update, err %v", err)
+		}
+		if _, err := store.Update(ctx, "m1", Movie{MovieID: "m1", MovieName: "Alien 3"}, 1); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("got %v updating a stale version, want ErrVersionConflict", err)
+		}
+		if _, err := store.Update(ctx, "m1", Movie{MovieID: "m1", MovieName: "Alien 3"}, 0); err != nil {
+			t.Fatalf("an update without a version failed: %v", err)
+		}
+		if _, err := store.Update(ctx, "nope", Movie{MovieID: "nope", MovieName: "Nope"}, 0); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v updating a missing movie, want ErrNotFound", err)
+		}
+
+		if err := store.Delete(ctx, "m1", 1); !errors.Is(err, ErrVersionConflict) {
+			t.Fatalf("got %v deleting a stale version, want ErrVersionConflict", err)
+		}
+		if err := store.Delete(ctx, "m1", 3); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Get(ctx, "m1"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v after the delete, want ErrNotFound", err)
+		}
+		if err := store.Delete(ctx, "m1", 0); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v deleting m1 again, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestStoreTrash(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		createMovies(t, store, Movie{MovieID: "m1", MovieName: "Alien"}, Movie{MovieID: "m2", MovieName: "Heat"})
+
+		for _, movieID := range []string{"m1", "m2"} {
+			if err := store.Delete(ctx, movieID, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		trash, err := store.ListTrash(ctx)
+		if err != nil || len(trash) != 2 || trash[0].DeletedAt == nil {
+			t.Fatalf("got trash %+v, %v, want both movies deleted", trash, err)
+		}
+		if got := listIDs(t, store, MovieQuery{}); got != "" {
+			t.Fatalf("got %s listed, want the trash left out", got)
+		}
+		trashed, err := store.GetTrashed(ctx, "m1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Create(ctx, Movie{MovieID: "m1", MovieName: "Aliens"}); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("got %v taking the movieid of a trashed movie, want ErrDuplicate", err)
+		}
+
+		restored, err := store.Restore(ctx, "m1")
+		if err != nil || restored.Version != trashed.Version+1 || restored.DeletedAt != nil {
+			t.Fatalf("got %+v, %v, want m1 back at version %d", restored, err, trashed.Version+1)
+		}
+		if _, err := store.Restore(ctx, "m1"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v restoring a movie outside the trash, want ErrNotFound", err)
+		}
+		if _, err := store.GetTrashed(ctx, "m1"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound for a restored movie", err)
+		}
+
+		if err := store.Purge(ctx, "m1"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v purging a movie outside the trash, want ErrNotFound", err)
+		}
+		if err := store.Purge(ctx, "m2"); err != nil {
+			t.Fatal(err)
+		}
+		if trash, err := store.ListTrash(ctx); err != nil || len(trash) != 0 {
+			t.Fatalf("got trash %+v, %v, want it empty", trash, err)
+		}
+		createMovies(t, store, Movie{MovieID: "m2", MovieName: "Heat"})
+	})
+}
+
+func TestStoreSnapshotRestore(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		createMovies(t, store, Movie{MovieID: "m1", MovieName: "Alien", ReleaseYear: 1979}, Movie{MovieID: "m2", MovieName: "Heat"})
+		if _, err := store.CreateGenre(ctx, Genre{GenreID: "scifi", Name: "Science fiction"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.AttachGenre(ctx, "m1", "scifi"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CreatePerson(ctx, Person{PersonID: "scott", Name: "Ridley Scott"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.AddCredit(ctx, "m1", Credit{PersonID: "scott", Role: "director"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Delete(ctx, "m2", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		snapshot, err := store.DeleteAll(ctx)
+		if err != nil || snapshot.MovieCount != 2 || snapshot.SnapshotID == "" {
+			t.Fatalf("got snapshot %+v, %v, want both movies saved", snapshot, err)
+		}
+		if count, err := store.CountAll(ctx); err != nil || count != 0 {
+			t.Fatalf("got %d movies left, %v, want none", count, err)
+		}
+		if trash, err := store.ListTrash(ctx); err != nil || len(trash) != 0 {
+			t.Fatalf("got trash %+v, %v, want the trash wiped too", trash, err)
+		}
+		if snapshots, err := store.ListSnapshots(ctx); err != nil || len(snapshots) != 1 || snapshots[0].SnapshotID != snapshot.SnapshotID {
+			t.Fatalf("got snapshots %+v, %v, want the one taken", snapshots, err)
+		}
+
+		if _, err := store.RestoreSnapshot(ctx, snapshot.SnapshotID); err != nil {
+			t.Fatal(err)
+		}
+		m, err := store.Get(ctx, "m1")
+		if err != nil || m.ReleaseYear != 1979 || len(m.Genres) != 1 || m.Genres[0].GenreID != "scifi" {
+			t.Fatalf("got %+v, %v, want m1 back with its genre", m, err)
+		}
+		if credits, err := store.ListCredits(ctx, "m1"); err != nil || len(credits) != 1 || credits[0].PersonID != "scott" {
+			t.Fatalf("got credits %+v, %v, want the director back", credits, err)
+		}
+		if _, err := store.GetTrashed(ctx, "m2"); err != nil {
+			t.Fatalf("m2 did not go back to the trash: %v", err)
+		}
+		if _, err := store.RestoreSnapshot(ctx, snapshot.SnapshotID); !errors.Is(err, ErrSnapshotNotFound) {
+			t.Fatalf("got %v restoring a used snapshot, want ErrSnapshotNotFound", err)
+		}
+
+		// A movieid taken again blocks the whole restore
+		snapshot, err = store.DeleteAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		createMovies(t, store, Movie{MovieID: "m2", MovieName: "Heat 2"})
+		if _, err := store.RestoreSnapshot(ctx, snapshot.SnapshotID); !errors.Is(err, ErrDuplicate) {
+			t.Fatalf("got %v, want ErrDuplicate", err)
+		}
+		if _, err := store.Get(ctx, "m1"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v, want nothing restored", err)
+		}
+
+		if err := store.DeleteSnapshot(ctx, snapshot.SnapshotID); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteSnapshot(ctx, snapshot.SnapshotID); !errors.Is(err, ErrSnapshotNotFound) {
+			t.Fatalf("got %v deleting a snapshot twice, want ErrSnapshotNotFound", err)
+		}
+	})
+}
+
+func TestStoreCreateMany(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		createMovies(t, store, Movie{MovieID: "m1", MovieName: "Alien"})
+
+		batch := []Movie{{MovieID: "m2", MovieName: "Heat"}, {MovieID: "m1", MovieName: "Aliens"}, {MovieID: "m2", MovieName: "Heat 2"}, {MovieID: "m3", MovieName: "Jaws"}}
+		outcomes := func(results []CreateResult) string {
+			var got []string
+			for _, result := range results {
+				switch {
+				case result.Err == nil:
+					got = append(got, "ok")
+				case errors.Is(result.Err, ErrDuplicate):
+					got = append(got, "duplicate")
+				case errors.Is(result.Err, ErrBatchAborted):
+					got = append(got, "aborted")
+				default:
+					got = append(got, result.Err.Error())
+				}
+			}
+			return strings.Join(got, ",")
+		}
+
+		tests := []struct {
+			name string
+			opts BatchOptions
+			want string
+			// wantIDs are the movies stored after the batch
+			wantIDs string
+		}{
+			{name: "atomic dry run", opts: BatchOptions{Atomic: true, DryRun: true}, want: "aborted,duplicate,duplicate,aborted", wantIDs: "m1"},
+			{name: "atomic", opts: BatchOptions{Atomic: true}, want: "aborted,duplicate,duplicate,aborted", wantIDs: "m1"},
+			{name: "best effort dry run", opts: BatchOptions{DryRun: true}, want: "ok,duplicate,duplicate,ok", wantIDs: "m1"},
+			{name: "best effort", opts: BatchOptions{}, want: "ok,duplicate,duplicate,ok", wantIDs: "m1,m2,m3"},
+		}
+
+		for _, test := range tests {
+			results, err := store.CreateMany(ctx, batch, test.opts)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			if got := outcomes(results); got != test.want {
+				t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+			}
+			if got := listIDs(t, store, MovieQuery{}); got != test.wantIDs {
+				t.Errorf("%s: got %s stored, want %s", test.name, got, test.wantIDs)
+			}
+		}
+	})
+}
+
+func TestStoreFilters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		createMovies(t, store,
+			Movie{MovieID: "m1", MovieName: "Alien", ReleaseYear: 1979, RuntimeMinutes: 117, Rating: "R", OriginalLanguage: "en"},
+			Movie{MovieID: "m2", MovieName: "Aliens", ReleaseYear: 1986, Rating: "R"},
+			Movie{MovieID: "m3", MovieName: "Heat", Rating: "R"},
+			Movie{MovieID: "m4", MovieName: "Amélie", ReleaseYear: 2001, RuntimeMinutes: 122, OriginalLanguage: "fr"},
+		)
+		if _, err := store.CreateGenre(ctx, Genre{GenreID: "scifi", Name: "Science fiction"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, movieID := range []string{"m2", "m1"} {
+			if err := store.AttachGenre(ctx, movieID, "scifi"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tests := []struct {
+			name string
+			q    MovieQuery
+			want string
+		}{
+			{name: "every movie", q: MovieQuery{}, want: "m1,m2,m3,m4"},
+			{name: "equal", q: MovieQuery{Conditions: []MovieCondition{{Field: "rating", Op: opEqual, Value: "R"}}}, want: "m1,m2,m3"},
+			{name: "prefix ignoring case", q: MovieQuery{Conditions: []MovieCondition{{Field: "moviename", Op: opPrefix, Value: "ALI"}}}, want: "m1,m2"},
+			{name: "range leaving out movies without a year", q: MovieQuery{Conditions: []MovieCondition{{Field: "release_year", Op: opMax, Value: 1990}}}, want: "m1,m2"},
+			{name: "minimum leaving out movies without a runtime", q: MovieQuery{Conditions: []MovieCondition{{Field: "runtime_minutes", Op: opMin, Value: 0}}}, want: "m1,m4"},
+			{name: "several conditions", q: MovieQuery{Conditions: []MovieCondition{{Field: "rating", Op: opEqual, Value: "R"}, {Field: "release_year", Op: opMin, Value: 1980}}}, want: "m2"},
+			{name: "in", q: MovieQuery{Conditions: []MovieCondition{{Field: "movieid", Op: opIn, Value: []string{"m4", "m2", "m9"}}}}, want: "m2,m4"},
+			{name: "genre", q: MovieQuery{Genre: "scifi"}, want: "m1,m2"},
+			{name: "unknown genre", q: MovieQuery{Genre: "nope"}, want: ""},
+			{name: "sorted descending with movies without a year last", q: MovieQuery{Sort: []SortKey{{Field: "release_year", Desc: true}, {Field: "id"}}}, want: "m4,m2,m1,m3"},
+			{name: "sorted by name", q: MovieQuery{Sort: []SortKey{{Field: "moviename"}, {Field: "id"}}}, want: "m1,m2,m4,m3"},
+			{name: "limit and offset", q: MovieQuery{Limit: 2, Offset: 1}, want: "m2,m3"},
+			{name: "after a keyset", q: MovieQuery{Sort: []SortKey{{Field: "moviename"}, {Field: "id"}}, After: []interface{}{"Aliens", 2}, Limit: 2}, want: "m4,m3"},
+		}
+
+		for _, test := range tests {
+			if got := listIDs(t, store, test.q); got != test.want {
+				t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+			}
+
+			// Count gives the length of the list when it is not paged
+			if test.q.Limit == 0 {
+				want := 0
+				if test.want != "" {
+					want = len(strings.Split(test.want, ","))
+				}
+				if count, err := store.Count(ctx, test.q); err != nil || count != want {
+					t.Errorf("%s: got a count of %d, %v, want %d", test.name, count, err, want)
+				}
+			}
+		}
+
+		deleted, err := store.DeleteMany(ctx, MovieQuery{Conditions: []MovieCondition{{Field: "release_year", Op: opMax, Value: 1990}}})
+		if err != nil || movieIDs(deleted) != "m1,m2" || deleted[0].DeletedAt == nil {
+			t.Fatalf("got %+v, %v, want m1 and m2 in the trash", deleted, err)
+		}
+		if got := listIDs(t, store, MovieQuery{}); got != "m3,m4" {
+			t.Fatalf("got %s left, want the movie without a year kept", got)
+		}
+	})
+}
+
+func TestStoreStream(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		createMovies(t, store, Movie{MovieID: "m1", MovieName: "Heat"}, Movie{MovieID: "m2", MovieName: "Alien"}, Movie{MovieID: "m3", MovieName: "Jaws"})
+		if _, err := store.CreateGenre(ctx, Genre{GenreID: "crime", Name: "Crime"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.AttachGenre(ctx, "m1", "crime"); err != nil {
+			t.Fatal(err)
+		}
+
+		q := MovieQuery{Sort: []SortKey{{Field: "moviename"}, {Field: "id"}}}
+		var streamed []Movie
+		err := store.Stream(ctx, q, func(m Movie) error {
+			streamed = append(streamed, m)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := movieIDs(streamed), listIDs(t, store, q); got != want {
+			t.Fatalf("streamed %s, want %s like List", got, want)
+		}
+		if heat := streamed[1]; len(heat.Genres) != 1 || heat.Genres[0].GenreID != "crime" {
+			t.Fatalf("got %+v, want Heat with its genre", heat)
+		}
+
+		// The first error of fn stops the stream
+		stop := errors.New("stop")
+		var count int
+		err = store.Stream(ctx, q, func(m Movie) error {
+			count++
+			return stop
+		})
+		if !errors.Is(err, stop) || count != 1 {
+			t.Fatalf("got %v after %d movies, want stop after 1", err, count)
+		}
+	})
+}
+
+func TestStoreAtomically(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		createMovies(t, store, Movie{MovieID: "m1", MovieName: "Alien"})
+
+		failed := errors.New("failed")
+		err := store.Atomically(ctx, func(store Store) error {
+			createMovies(t, store, Movie{MovieID: "m2", MovieName: "Heat"})
+			if err := store.Delete(ctx, "m1", 0); err != nil {
+				return err
+			}
+			if err := store.RecordAudit(ctx, AuditEntry{Action: "create", MovieID: "m2"}); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Fatalf("got %v, want the error of fn", err)
+		}
+		if got := listIDs(t, store, MovieQuery{}); got != "m1" {
+			t.Fatalf("got %s, want the changes discarded", got)
+		}
+		if entries, err := store.ListAudit(ctx, AuditFilter{}); err != nil || len(entries) != 0 {
+			t.Fatalf("got audit entries %+v, %v, want none", entries, err)
+		}
+
+		err = store.Atomically(ctx, func(store Store) error {
+			createMovies(t, store, Movie{MovieID: "m2", MovieName: "Heat"})
+			return store.RecordAudit(ctx, AuditEntry{Action: "create", MovieID: "m2"})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := listIDs(t, store, MovieQuery{}); got != "m1,m2" {
+			t.Fatalf("got %s, want m2 kept", got)
+		}
+		if entries, err := store.ListAudit(ctx, AuditFilter{}); err != nil || len(entries) != 1 {
+			t.Fatalf("got audit entries %+v, %v, want the one recorded", entries, err)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+// streamFlushRows is how many movies are streamed between flushes, so that
+// the client sees the response progress without a flush for every movie
+const streamFlushRows = 100
+
+// movieStream writes a successful JsonResponse one movie at a time. Nothing
+// is written until the first movie or close, so that a failure before then
+// can still be answered with an error status.
+type movieStream struct {
+	writer io.Writer
+	flush  func()
+	// fields are the movie fields to encode, or nil for all of them
+	fields  []string
+	started bool
+	count   int
+}
+
+func newMovieStream(writer http.ResponseWriter, fields []string) *movieStream {
+	flush := func() {}
+	if flusher, ok := writer.(http.Flusher); ok {
+		flush = flusher.Flush
+	}
+
+	return &movieStream{writer: writer, flush: flush, fields: fields}
+}
+
+// start writes the response up to the movies, keeping the field order of JsonResponse
+func (s *movieStream) start() error {
+	s.started = true
+	_, err := io.WriteString(s.writer, `{"type":"success","data":[`)
+	return err
+}
+
+func (s *movieStream) write(m Movie) error {
+	var data []byte
+	var err error
+	if s.fields != nil {
+		data, err = projectMovie(m, s.fields)
+	} else {
+		data, err = json.Marshal(m)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !s.started {
+		err = s.start()
+	} else {
+		_, err = io.WriteString(s.writer, ",")
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.writer.Write(data); err != nil {
+		return err
+	}
+
+	s.count++
+	if s.count%streamFlushRows == 0 {
+		s.flush()
+	}
+	return nil
+}
+
+// close ends the movies and writes the message and metadata after them.
+// The total of the metadata is the number of movies written.
+func (s *movieStream) close(message string) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	tail, err := json.Marshal(struct {
+		Message string    `json:"message"`
+		Meta    *ListMeta `json:"meta"`
+	}{message, &ListMeta{Total: s.count}})
+	if err != nil {
+		return err
+	}
+
+	// The tail is an object of its own, so its opening brace gives way to
+	// the end of the data
+	tail[0] = ','
+	if _, err := io.WriteString(s.writer, "]"); err != nil {
+		return err
+	}
+	if _, err := s.writer.Write(append(tail, '\n')); err != nil {
+		return err
+	}
+
+	s.flush()
+	return nil
+}
+
+// streamMovies lists the movies selected by q as they are read from the
+// store, so that listing every movie does not gather them in memory first
+func (s *server) streamMovies(writer http.ResponseWriter, reader *http.Request, q MovieQuery) {
+	stream := newMovieStream(writer, q.Fields)
+	err := s.store.Stream(reader.Context(), q, stream.write)
+	if err == nil {
+		err = stream.close("Successfully got all movies from DB")
+	}
+
+	if err != nil && !stream.started {
+		writer.WriteHeader(http.StatusInternalServerError)
+		var response = JsonResponse{Type: "error", Message: "Failed to get all movies from the database"}
+		json.NewEncoder(writer).Encode(response)
+		return
+	} else if err != nil {
+		// The status has been sent, so the response is cut off instead of
+		// ending as if the list was complete
+		log.Println("Listing movies failed after", stream.count, "movies:", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	printMessage("Successfully got all movies from DB")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// flushCounter records a response and counts how many times it is flushed
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (w *flushCounter) Flush() {
+	w.flushes++
+	w.ResponseRecorder.Flush()
+}
+
+func TestStreamMovies(t *testing.T) {
+	tests := []struct {
+		name   string
+		movies int
+		// flushes is how many times the list is flushed: every
+		// streamFlushRows movies, then once it is complete
+		flushes int
+	}{
+		{name: "empty", movies: 0, flushes: 1},
+		{name: "one movie", movies: 1, flushes: 1},
+		{name: "several batches", movies: 2*streamFlushRows + 50, flushes: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newMemoryStore()
+			for i := 1; i <= test.movies; i++ {
+				if _, err := store.Create(context.Background(), Movie{MovieID: "m" + strconv.Itoa(i), MovieName: "Alien"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			writer := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+			newRouter(newServer(store)).ServeHTTP(writer, httptest.NewRequest("GET", "/movies/", nil))
+
+			if !json.Valid(writer.Body.Bytes()) {
+				t.Fatalf("the list is not valid JSON: %s", writer.Body.String())
+			}
+			response := decodeMovies(t, writer.ResponseRecorder, http.StatusOK)
+			if response.Type != "success" || response.Data == nil || len(response.Data) != test.movies {
+				t.Fatalf("got %s with %d movies, want success with %d", response.Type, len(response.Data), test.movies)
+			}
+			if response.Meta == nil || response.Meta.Total != test.movies || response.Message == "" {
+				t.Fatalf("got meta %+v and message %q, want a total of %d and a message", response.Meta, response.Message, test.movies)
+			}
+			if test.movies > 0 && (response.Data[0].MovieID != "m1" || response.Data[test.movies-1].MovieID != "m"+strconv.Itoa(test.movies)) {
+				t.Fatalf("got movies from %s to %s, want them in order", response.Data[0].MovieID, response.Data[test.movies-1].MovieID)
+			}
+			if writer.flushes != test.flushes {
+				t.Fatalf("got %d flushes, want %d", writer.flushes, test.flushes)
+			}
+		})
+	}
+}
+
+func TestStreamMoviesNotModified(t *testing.T) {
+	router := testRouter(t)
+	addMovies(t, router, "m1:Alien", "m2:Heat")
+
+	etag := serve(t, router, "GET", "/movies/", "").Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("the list has no ETag")
+	}
+
+	recorder := serve(t, router, "GET", "/movies/", "", "If-None-Match", etag)
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 {
+		t.Fatalf("got status %d and %q, want an empty 304", recorder.Code, recorder.Body.String())
+	}
+	recorder = serve(t, router, "GET", "/movies/", "", "If-None-Match", `"other", `+etag)
+	if recorder.Code != http.StatusNotModified {
+		t.Fatalf("got status %d for a list of tags, want 304", recorder.Code)
+	}
+
+	// A change gives the list a new tag
+	addMovies(t, router, "m3:Jaws")
+	response := decodeMovies(t, serve(t, router, "GET", "/movies/", "", "If-None-Match", etag), http.StatusOK)
+	if len(response.Data) != 3 {
+		t.Fatalf("got %d movies, want the 3 of the changed list", len(response.Data))
+	}
+}
+
+func TestStreamMoviesFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		movies int
+		// aborted is whether the list has started, so that the connection
+		// is aborted rather than answered with a 500
+		aborted bool
+	}{
+		{name: "before the first movie", movies: 0},
+		{name: "after the first movie", movies: 1, aborted: true},
+		{name: "after a flush", movies: streamFlushRows + 1, aborted: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router := newRouter(newServer(failingStreamStore{newMemoryStore(), test.movies}))
+
+			defer func() {
+				if r := recover(); test.aborted && r != http.ErrAbortHandler {
+					t.Fatalf("got panic %v, want http.ErrAbortHandler", r)
+				} else if !test.aborted && r != nil {
+					t.Fatalf("got panic %v, want a 500", r)
+				}
+			}()
+
+			recorder := serve(t, router, "GET", "/movies/", "")
+			if test.aborted {
+				t.Fatalf("got status %d, want the list to be aborted", recorder.Code)
+			}
+			response := decodeMovies(t, recorder, http.StatusInternalServerError)
+			if response.Type != "error" || response.Data != nil {
+				t.Fatalf("got %+v, want an error without movies", response)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// listTrash godoc
+// @Description Get the deleted movies that can still be restored, most recently deleted first
+// @Produce json
+// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully get the movies in the trash"
+// @Failure 500 {object} JsonResponse{type=string,message=string} "Fail to get the movies in the trash"
+// @Router /trash/ [get]
+func (s *server) listTrash(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: GET /trash")
+
+	movies, err := s.store.ListTrash(reader.Context())
+
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(JsonResponse{Type: "error", Message: "Failed to get the trash from the database"})
+		return
+	}
+
+	json.NewEncoder(writer).Encode(JsonResponse{Type: "success", Data: movies, Message: "Successfully got the trash from DB"})
+}
+
+// restoreMovie godoc
+// @Description Take a deleted movie out of the trash, with its genres and credits
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Success 200 {object} JsonResponse{type=string,data=[]Movie,message=string} "Successfully restore the movie"
+// @Header 200 {string} ETag "Row ID and new version of the movie"
+// @Failure 404 {object} JsonResponse{type=string,message=string} "No movie with the specified movieid is in the trash"
+// @Router /trash/{movieid}/restore/ [post]
+func (s *server) restoreMovie(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: POST /trash/{movieid}/restore")
+
+	movieID := mux.Vars(reader)["movieid"]
+	var m Movie
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		var err error
+		m, err = store.Restore(reader.Context(), movieID)
+		return []AuditEntry{auditEntry("restore", movieID, nil, m)}, err
+	})
+
+	var response = JsonResponse{}
+
+	if errors.Is(err, ErrNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = JsonResponse{Type: "failure", Message: "A movie with that movieid is not in the trash."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: "Failed to restore the movie"}
+	} else {
+		s.titles.add(m.title())
+		writer.Header().Set("ETag", movieETag(m))
+		response = JsonResponse{Type: "success", Data: []Movie{m}, Message: "The movie has been restored successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}
+
+// purgeMovie godoc
+// @Description Permanently delete a movie in the trash, with its genres and credits
+// @Produce json
+// @Param movieid path string true "Movie ID"
+// @Success 200 {object} JsonResponse{type=string,message=string} "Successfully purge the movie"
+// @Failure 404 {object} JsonResponse{type=string,message=string} "No movie with the specified movieid is in the trash"
+// @Router /trash/{movieid}/ [delete]
+func (s *server) purgeMovie(writer http.ResponseWriter, reader *http.Request) {
+	log.Println("Endpoint hit: DELETE /trash/{movieid}")
+
+	movieID := mux.Vars(reader)["movieid"]
+
+	err := s.audited(reader, func(store Store) ([]AuditEntry, error) {
+		// The movie is read first for the audit log, which is all that is
+		// left of it once it is purged
+		before, err := store.GetTrashed(reader.Context(), movieID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = store.Purge(reader.Context(), movieID)
+		return []AuditEntry{auditEntry("purge", movieID, before, nil)}, err
+	})
+
+	var response = JsonResponse{}
+
+	// Unlike deleteMovie, a miss is reported, since the movie may still be live
+	if errors.Is(err, ErrNotFound) {
+		writer.WriteHeader(http.StatusNotFound)
+		response = JsonResponse{Type: "failure", Message: "A movie with that movieid is not in the trash."}
+	} else if errors.Is(err, errNotAudited) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: notAuditedMessage}
+	} else if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		response = JsonResponse{Type: "error", Message: "Failed to purge the movie"}
+	} else {
+		response = JsonResponse{Type: "success", Message: "The movie has been purged successfully."}
+	}
+
+	json.NewEncoder(writer).Encode(response)
+}